@@ -47,20 +47,8 @@ func main() {
 func getConfig() (string, models.DriveConfig) {
 	loadEnvFile(".env")
 
-	PGHOST := os.Getenv("PGHOST")
-	PGUSER := os.Getenv("PGUSER")
-	PGPASSWORD := os.Getenv("PGPASSWORD")
-	PGPORT := os.Getenv("PGPORT")
-	PGDATABASE := os.Getenv("PGDATABASE")
-	PGSSLMODE := os.Getenv("PGSSLMODE")
-
-	connStr := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s", PGHOST, PGUSER, PGPASSWORD, PGDATABASE, PGPORT, PGSSLMODE)
-
-	PGURL := os.Getenv("PGURL")
-
-	if PGURL != "" {
-		connStr = PGURL
-	}
+	// PGHOST, PGUSER and the other libpq variables are read by the library
+	connStr := os.Getenv("PGURL")
 
 	PGVERBOSE := parseBoolEnv(os.Getenv("PGVERBOSE"))
 
@@ -0,0 +1,25 @@
+package pool
+
+// ReadBuffer is a backend message buffer reused from one message to the
+// next, so slices of a message are only valid until the next read.
+type ReadBuffer struct {
+	Bytes []byte
+}
+
+func NewReadBuffer(bufSize int) *ReadBuffer {
+	return &ReadBuffer{
+		Bytes: make([]byte, 0, bufSize),
+	}
+}
+
+// Grow returns the first n bytes of the buffer, keeping its content and
+// reallocating it when it is too small.
+func (buf *ReadBuffer) Grow(n int) []byte {
+	if n > cap(buf.Bytes) {
+		bytes := make([]byte, n)
+		copy(bytes, buf.Bytes)
+		buf.Bytes = bytes
+	}
+	buf.Bytes = buf.Bytes[:n]
+	return buf.Bytes
+}
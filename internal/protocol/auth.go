@@ -6,25 +6,50 @@ import (
 	"crypto/md5"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	pbkdf2 "postgres-protocol-go"
 	"postgres-protocol-go/internal/pool"
 	"postgres-protocol-go/internal/protocol/messages"
+	"postgres-protocol-go/internal/saslprep"
 	"postgres-protocol-go/pkg/utils"
+	"slices"
 	"strconv"
 	"strings"
 )
 
+// SCRAM mechanisms, see
+// https://www.postgresql.org/docs/current/sasl-authentication.html
+const (
+	scramSHA256     = "SCRAM-SHA-256"
+	scramSHA256Plus = "SCRAM-SHA-256-PLUS"
+)
+
+// https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNECT-CHANNEL-BINDING
+const (
+	channelBindingDisable = "disable"
+	channelBindingPrefer  = "prefer"
+	channelBindingRequire = "require"
+)
+
 func ProcessAuth(pgConnection PgConnection) error {
 	var (
 		saslMethod        string
+		gs2Header         string
+		cbData            []byte
 		clientNonce       string
 		expectedServerSig []byte
+		serverVerified    bool
 	)
 
+	channelBinding := pgConnection.connConfig.ChannelBinding
+
 	for {
 		answer, err := pgConnection.readMessage()
 		if err != nil {
@@ -40,16 +65,22 @@ func ProcessAuth(pgConnection PgConnection) error {
 
 		switch authType {
 		case authenticationOk:
+			if channelBinding == channelBindingRequire && (saslMethod != scramSHA256Plus || !serverVerified) {
+				return fmt.Errorf("channel binding required, but server authenticated client without channel binding")
+			}
 			if pgConnection.isVerbose() {
 				fmt.Println("Authentication successful")
 				fmt.Println("Waiting for ReadyForQuery message")
 			}
 			return waitForReady(pgConnection)
 		case authenticationSASL:
-			saslMethod = strings.Trim(string(answer[9:]), "\x00 \n\r")
+			saslMethod, gs2Header, cbData, err = selectSASLMechanism(pgConnection, parseSASLMechanisms(answer))
+			if err != nil {
+				return err
+			}
 			switch saslMethod {
-			case "SCRAM-SHA-256":
-				nonce, initialResponse, err := buildSCRAMInitialResponse(pgConnection.connConfig.User)
+			case scramSHA256, scramSHA256Plus:
+				nonce, initialResponse, err := buildSCRAMInitialResponse(gs2Header, scramUsername(pgConnection.connConfig.User))
 				if err != nil {
 					return err
 				}
@@ -70,7 +101,7 @@ func ProcessAuth(pgConnection PgConnection) error {
 			}
 		case authenticationSASLContinue:
 			switch saslMethod {
-			case "SCRAM-SHA-256":
+			case scramSHA256, scramSHA256Plus:
 				if clientNonce == "" {
 					return fmt.Errorf("client nonce not available")
 				}
@@ -113,7 +144,7 @@ func ProcessAuth(pgConnection PgConnection) error {
 					return fmt.Errorf("failed to decode salt: %v", err)
 				}
 
-				password := []byte(*pgConnection.connConfig.Password)
+				password := []byte(saslprep.Password(*pgConnection.connConfig.Password))
 				saltedPassword := pbkdf2.Key(password, salt, iterations, 32, sha256.New)
 
 				clientKey := hmac.New(sha256.New, saltedPassword)
@@ -122,9 +153,10 @@ func ProcessAuth(pgConnection PgConnection) error {
 
 				storedKey := sha256.Sum256(clientKeyBytes)
 
-				clientFirstBare := fmt.Sprintf("n=%s,r=%s", pgConnection.connConfig.User, clientNonce)
+				clientFirstBare := fmt.Sprintf("n=%s,r=%s", scramUsername(pgConnection.connConfig.User), clientNonce)
 				serverFirst := fmt.Sprintf("r=%s,s=%s,i=%d", serverNonce, saltB64, iterations)
-				clientFinalWithoutProof := fmt.Sprintf("c=biws,r=%s", serverNonce)
+				channelBindingB64 := base64.StdEncoding.EncodeToString(append([]byte(gs2Header), cbData...))
+				clientFinalWithoutProof := fmt.Sprintf("c=%s,r=%s", channelBindingB64, serverNonce)
 				authMessage := strings.Join([]string{clientFirstBare, serverFirst, clientFinalWithoutProof}, ",")
 
 				clientSignature := hmac.New(sha256.New, storedKey[:])
@@ -145,7 +177,7 @@ func ProcessAuth(pgConnection PgConnection) error {
 				expectedServerSigHasher.Write([]byte(authMessage))
 				expectedServerSig = expectedServerSigHasher.Sum(nil)
 
-				clientFinalMessage := fmt.Sprintf("c=biws,r=%s,p=%s", serverNonce, clientProofB64)
+				clientFinalMessage := fmt.Sprintf("%s,p=%s", clientFinalWithoutProof, clientProofB64)
 
 				buf := pool.NewWriteBuffer(1024)
 				buf.StartMessage(messages.SASLResponse)
@@ -160,10 +192,12 @@ func ProcessAuth(pgConnection PgConnection) error {
 			}
 		case authenticationSASLFinal:
 			switch saslMethod {
-			case "SCRAM-SHA-256":
+			case scramSHA256, scramSHA256Plus:
 				serverMessage := string(answer[9:])
 
-				fmt.Println("server messsage:", serverMessage)
+				if pgConnection.isVerbose() {
+					fmt.Println("server message:", serverMessage)
+				}
 				var serverSigB64 string
 				for _, part := range strings.Split(serverMessage, ",") {
 					kv := strings.SplitN(part, "=", 2)
@@ -188,11 +222,15 @@ func ProcessAuth(pgConnection PgConnection) error {
 				if !bytes.Equal(serverSig, expectedServerSig) {
 					return fmt.Errorf("server signature mismatch")
 				}
+				serverVerified = true
 			default:
 				return fmt.Errorf("SASL authentication method %s is not supported", saslMethod)
 			}
 
 		case authenticationMD5Password:
+			if channelBinding == channelBindingRequire {
+				return fmt.Errorf("channel binding required, but server requested MD5 authentication")
+			}
 			if pgConnection.connConfig.Password == nil {
 				return fmt.Errorf("password is required for MD5 authentication")
 			}
@@ -209,6 +247,9 @@ func ProcessAuth(pgConnection PgConnection) error {
 			}
 
 		case authenticationCleartextPassword:
+			if channelBinding == channelBindingRequire {
+				return fmt.Errorf("channel binding required, but server requested cleartext password authentication")
+			}
 			if pgConnection.connConfig.Password == nil {
 				return fmt.Errorf("password is required for cleartext authentication")
 			}
@@ -239,6 +280,13 @@ func waitForReady(pgConnection PgConnection) error {
 		switch identifier {
 		case messages.ReadyForQuery:
 			return nil
+		case messages.ParameterStatus:
+			if pgConnection.serverParams != nil {
+				name, value, _ := strings.Cut(string(message[5:]), "\x00")
+				pgConnection.serverParams[name] = strings.TrimSuffix(value, "\x00")
+			}
+		case messages.BackendKeyData:
+			parseBackendKeyData(message, pgConnection.backendKey)
 		default:
 			if pgConnection.isVerbose() {
 				fmt.Printf("Auth: Unknown message: %s\n", string(message))
@@ -247,12 +295,90 @@ func waitForReady(pgConnection PgConnection) error {
 	}
 }
 
-func buildSCRAMInitialResponse(username string) (string, []byte, error) {
+// parseSASLMechanisms returns the mechanisms of an AuthenticationSASL
+// message, a list of null-terminated names ended by an empty name.
+func parseSASLMechanisms(message []byte) []string {
+	var mechanisms []string
+	for _, name := range strings.Split(string(message[9:]), "\x00") {
+		if name == "" {
+			break
+		}
+		mechanisms = append(mechanisms, name)
+	}
+	return mechanisms
+}
+
+// selectSASLMechanism picks SCRAM-SHA-256-PLUS over TLS when the server
+// offers it and channel_binding allows it, SCRAM-SHA-256 otherwise. It
+// returns the GS2 header of the mechanism and the channel binding data.
+func selectSASLMechanism(pgConnection PgConnection, mechanisms []string) (mechanism, gs2Header string, cbData []byte, err error) {
+	channelBinding := pgConnection.connConfig.ChannelBinding
+	tlsConn, isTLS := pgConnection.conn.(*tls.Conn)
+	clientSupportsCB := isTLS && channelBinding != channelBindingDisable
+
+	if clientSupportsCB && slices.Contains(mechanisms, scramSHA256Plus) {
+		cbData, err = tlsServerEndPoint(tlsConn.ConnectionState())
+		if err != nil {
+			return "", "", nil, err
+		}
+		return scramSHA256Plus, "p=tls-server-end-point,,", cbData, nil
+	}
+
+	if channelBinding == channelBindingRequire {
+		if !isTLS {
+			return "", "", nil, fmt.Errorf("channel binding is required, but SSL is not in use")
+		}
+		return "", "", nil, fmt.Errorf("channel binding is required, but server did not offer an authentication method that supports channel binding")
+	}
+
+	if !slices.Contains(mechanisms, scramSHA256) {
+		return "", "", nil, fmt.Errorf("SASL authentication methods %s are not supported", strings.Join(mechanisms, ", "))
+	}
+	// y tells the server that the client supports channel binding but
+	// thinks the server does not, which detects a downgrade.
+	if clientSupportsCB {
+		return scramSHA256, "y,,", nil, nil
+	}
+	return scramSHA256, "n,,", nil, nil
+}
+
+// tlsServerEndPoint returns the tls-server-end-point channel binding data
+// of RFC 5929: the hash of the server certificate, with the hash of its
+// signature algorithm, or SHA-256 when that is MD5 or SHA-1.
+func tlsServerEndPoint(state tls.ConnectionState) ([]byte, error) {
+	if len(state.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("server did not send a certificate for channel binding")
+	}
+	cert := state.PeerCertificates[0]
+
+	var h hash.Hash
+	switch cert.SignatureAlgorithm {
+	case x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1,
+		x509.SHA256WithRSA, x509.SHA256WithRSAPSS, x509.DSAWithSHA256, x509.ECDSAWithSHA256:
+		h = sha256.New()
+	case x509.SHA384WithRSA, x509.SHA384WithRSAPSS, x509.ECDSAWithSHA384:
+		h = sha512.New384()
+	case x509.SHA512WithRSA, x509.SHA512WithRSAPSS, x509.ECDSAWithSHA512:
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("could not find digest for the server certificate signature algorithm %s", cert.SignatureAlgorithm)
+	}
+	h.Write(cert.Raw)
+	return h.Sum(nil), nil
+}
+
+// scramUsername escapes = and , in user as =3D and =2C, as in RFC 5802.
+// The server ignores this name and uses the one of the startup message.
+func scramUsername(user string) string {
+	return strings.NewReplacer("=", "=3D", ",", "=2C").Replace(user)
+}
+
+func buildSCRAMInitialResponse(gs2Header, username string) (string, []byte, error) {
 	nonce, err := generateNonce()
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to generate nonce: %v", err)
 	}
-	initialResponse := fmt.Sprintf("n,,n=%s,r=%s", username, nonce)
+	initialResponse := fmt.Sprintf("%sn=%s,r=%s", gs2Header, username, nonce)
 	return nonce, []byte(initialResponse), nil
 }
 
@@ -0,0 +1,64 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"postgres-protocol-go/internal/pool"
+	"postgres-protocol-go/internal/protocol/messages"
+	"time"
+)
+
+// cancelRequestCode is the protocol version of a CancelRequest.
+const cancelRequestCode = 80877102
+
+// cancelTimeout bounds sending a CancelRequest, and how long the canceled
+// query is then given to end before the connection is interrupted.
+const cancelTimeout = 5 * time.Second
+
+// backendKey identifies the session in CancelRequests, the server sends it
+// in BackendKeyData at startup.
+type backendKey struct {
+	processID uint32
+	secret    []byte
+}
+
+// parseBackendKeyData stores the process ID and secret key of a
+// BackendKeyData message in key.
+func parseBackendKeyData(message []byte, key *backendKey) {
+	if key == nil || len(message) < 9 {
+		return
+	}
+	key.processID = binary.BigEndian.Uint32(message[5:9])
+	key.secret = append([]byte{}, message[9:]...)
+}
+
+// sendCancelRequest asks the server, over a new connection, to cancel the
+// query in progress. The server ends the query with an error, which leaves
+// the connection ready for the next one.
+func (pg *PgConnection) sendCancelRequest() error {
+	if pg.backendKey == nil || pg.backendKey.secret == nil {
+		return errors.New("the server sent no cancellation key")
+	}
+
+	addr := pg.conn.RemoteAddr()
+	conn, err := net.DialTimeout(addr.Network(), addr.String(), cancelTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to send the cancel request: %w", err)
+	}
+	defer conn.Close()
+
+	buf := pool.NewWriteBuffer(16)
+	buf.StartMessage(messages.Cancel)
+	buf.WriteInt32(cancelRequestCode)
+	buf.WriteInt32(int32(pg.backendKey.processID))
+	buf.Write(pg.backendKey.secret)
+	buf.FinishMessage()
+
+	conn.SetDeadline(time.Now().Add(cancelTimeout))
+	if _, err := conn.Write(buf.Bytes); err != nil {
+		return fmt.Errorf("failed to send the cancel request: %w", err)
+	}
+	return nil
+}
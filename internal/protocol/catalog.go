@@ -0,0 +1,256 @@
+package protocol
+
+import (
+	"fmt"
+	"postgres-protocol-go/pkg/models"
+	"postgres-protocol-go/pkg/types"
+	"strconv"
+	"strings"
+)
+
+const compositeTypeQuery = `SELECT t.oid, a.attname, a.atttypid
+FROM pg_type t
+JOIN pg_attribute a ON a.attrelid = t.typrelid
+WHERE t.oid = to_regtype($1)::oid AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum`
+
+// loadCompositeTypes resolves every composite registered with
+// types.RegisterComposite and adds it to the connection type registry.
+func loadCompositeTypes(pgConnection PgConnection) error {
+	for _, name := range types.RegisteredComposites() {
+		res, err := ProcessExtendedQuery(pgConnection, compositeTypeQuery, name)
+		if err != nil {
+			return fmt.Errorf("failed to look up composite type %s: %w", name, err)
+		}
+
+		if len(res.Rows) == 0 {
+			if pgConnection.isVerbose() {
+				fmt.Printf("Composite type %s not found\n", name)
+			}
+			continue
+		}
+
+		var oid uint32
+		fields := make([]types.CompositeField, len(res.Rows))
+		for i, row := range res.Rows {
+			if oid, err = parseOID(row["oid"]); err != nil {
+				return err
+			}
+			attOID, err := parseOID(row["atttypid"])
+			if err != nil {
+				return err
+			}
+			fields[i] = types.CompositeField{Name: row["attname"].(string), OID: attOID}
+		}
+
+		composite := types.NewCompositeType(name, oid, fields)
+		pgConnection.typeRegistry.Register(composite.Type())
+	}
+	return nil
+}
+
+func parseOIDColumns(row map[string]interface{}, columns ...string) ([]uint32, error) {
+	oids := make([]uint32, len(columns))
+	for i, column := range columns {
+		oid, err := parseOID(row[column])
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", column, err)
+		}
+		oids[i] = oid
+	}
+	return oids, nil
+}
+
+func parseOID(value any) (uint32, error) {
+	s, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("expected OID as text, got %T", value)
+	}
+	oid, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid OID %q: %w", s, err)
+	}
+	return uint32(oid), nil
+}
+
+const hstoreTypeQuery = `SELECT oid, typarray FROM pg_type WHERE typname = 'hstore'`
+
+// LoadHstore looks up the OID of the hstore extension type so that hstore
+// columns are decoded into map[string]*string.
+func (pg *PgConnection) LoadHstore() error {
+	res, err := ProcessSimpleQuery(*pg, hstoreTypeQuery)
+	if err != nil {
+		return fmt.Errorf("failed to look up hstore type: %w", err)
+	}
+	if len(res.Rows) == 0 {
+		return fmt.Errorf("hstore extension is not installed")
+	}
+
+	oid, err := parseOID(res.Rows[0]["oid"])
+	if err != nil {
+		return err
+	}
+	arrayOID, err := parseOID(res.Rows[0]["typarray"])
+	if err != nil {
+		return err
+	}
+
+	pg.typeRegistry.Register(types.NewHstoreType(oid, arrayOID))
+	return nil
+}
+
+const userTypesQuery = `SELECT t.oid, format_type(t.oid, NULL) AS name, t.typtype, t.typcategory,
+	t.typbasetype, t.typelem, t.typarray, COALESCE(r.rngsubtype, 0) AS rngsubtype,
+	0 AS rngtypid
+FROM pg_type t
+JOIN pg_namespace n ON n.oid = t.typnamespace
+LEFT JOIN pg_range r ON r.rngtypid = t.oid
+WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+	AND t.typtype IN ('b', 'd', 'e', 'r')
+ORDER BY t.oid`
+
+// userTypesQuery14 adds the multiranges of PostgreSQL 14+ to userTypesQuery,
+// with the range type of each in rngtypid.
+const userTypesQuery14 = `SELECT t.oid, format_type(t.oid, NULL) AS name, t.typtype, t.typcategory,
+	t.typbasetype, t.typelem, t.typarray, COALESCE(r.rngsubtype, 0) AS rngsubtype,
+	COALESCE(mr.rngtypid, 0) AS rngtypid
+FROM pg_type t
+JOIN pg_namespace n ON n.oid = t.typnamespace
+LEFT JOIN pg_range r ON r.rngtypid = t.oid
+LEFT JOIN pg_range mr ON mr.rngmultitypid = t.oid
+WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+	AND t.typtype IN ('b', 'd', 'e', 'r', 'm')
+ORDER BY t.oid`
+
+const enumLabelsQuery = `SELECT enumtypid, enumlabel FROM pg_enum ORDER BY enumtypid, enumsortorder`
+
+// LoadTypes discovers the enums, domains, ranges, multiranges, extension
+// types and their arrays defined in the database and adds them to the type registry, so that
+// their columns are decoded instead of being returned raw. Composites
+// registered with types.RegisterComposite are resolved again. It can be
+// called at any time to pick up types created after connecting.
+func (pg *PgConnection) LoadTypes() error {
+	enumRes, err := ProcessSimpleQuery(*pg, enumLabelsQuery)
+	if err != nil {
+		return fmt.Errorf("failed to load enum labels: %w", err)
+	}
+
+	labels := make(map[uint32][]string)
+	for _, row := range enumRes.Rows {
+		oid, err := parseOID(row["enumtypid"])
+		if err != nil {
+			return err
+		}
+		labels[oid] = append(labels[oid], row["enumlabel"].(string))
+	}
+
+	query := userTypesQuery
+	if pg.serverMajorVersion() >= 14 {
+		query = userTypesQuery14
+	}
+	typeRes, err := ProcessSimpleQuery(*pg, query)
+	if err != nil {
+		return fmt.Errorf("failed to load user types: %w", err)
+	}
+
+	var arrays []map[string]interface{}
+	for _, row := range typeRes.Rows {
+		// Domains over arrays are in the array category too, only base
+		// types with an element type are arrays.
+		if row["typtype"] == "b" && row["typcategory"] == "A" && row["typelem"] != "0" {
+			arrays = append(arrays, row)
+			continue
+		}
+
+		oids, err := parseOIDColumns(row, "oid", "typbasetype", "typarray", "rngsubtype", "rngtypid")
+		if err != nil {
+			return err
+		}
+		oid, baseOID, arrayOID, subtype, rangeOID := oids[0], oids[1], oids[2], oids[3], oids[4]
+		name := row["name"].(string)
+
+		switch types.TypeKind(row["typtype"].(string)[0]) {
+		case types.EnumKind:
+			pg.typeRegistry.Register(types.NewEnumType(oid, name, arrayOID, labels[oid]))
+		case types.DomainKind:
+			pg.typeRegistry.Register(types.NewDomainType(pg.typeRegistry, oid, name, arrayOID, baseOID))
+		case types.RangeKind:
+			pg.typeRegistry.Register(types.NewRangeType(oid, name, arrayOID, subtype))
+		case types.MultirangeKind:
+			pg.typeRegistry.Register(types.NewMultirangeType(oid, name, arrayOID, rangeOID))
+		default:
+			if name == "hstore" {
+				pg.typeRegistry.Register(types.NewHstoreType(oid, arrayOID))
+			} else {
+				pg.typeRegistry.Register(&types.Type{OID: oid, Name: name, Kind: types.BaseKind, ArrayOID: arrayOID})
+			}
+		}
+	}
+
+	// Arrays are registered last so that their element types are known.
+	for _, row := range arrays {
+		oids, err := parseOIDColumns(row, "oid", "typelem")
+		if err != nil {
+			return err
+		}
+		pg.typeRegistry.Register(types.NewArrayType(pg.typeRegistry, oids[0], row["name"].(string), oids[1]))
+	}
+
+	return loadCompositeTypes(*pg)
+}
+
+// serverMajorVersion returns the major version of the server from its
+// server_version parameter, 0 when it is unknown.
+func (pg *PgConnection) serverMajorVersion() int {
+	version := pg.serverParams["server_version"]
+	end := strings.IndexFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	if end == -1 {
+		end = len(version)
+	}
+	major, _ := strconv.Atoi(version[:end])
+	return major
+}
+
+const columnInfoQuery = `SELECT n.nspname, c.relname, a.attname, a.attnotnull
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE a.attrelid = $1 AND a.attnum = $2`
+
+type columnKey struct {
+	tableOID uint32
+	attrNum  uint16
+}
+
+// ColumnInfo returns the table column field comes from, looked up in the
+// catalog the first time and cached on the connection. It returns nil when
+// field is not a table column.
+func (pg *PgConnection) ColumnInfo(field models.Field) (*models.ColumnInfo, error) {
+	if field.TableOID == 0 {
+		return nil, nil
+	}
+
+	key := columnKey{tableOID: field.TableOID, attrNum: field.AttrNum}
+	if info, ok := pg.columns.Load(key); ok {
+		return info.(*models.ColumnInfo), nil
+	}
+
+	res, err := ProcessExtendedQuery(*pg, columnInfoQuery,
+		types.Typed(types.OIDOID, field.TableOID), types.Typed(types.Int2OID, field.AttrNum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up column %s: %w", field.Name, err)
+	}
+
+	var info *models.ColumnInfo
+	if len(res.Rows) > 0 {
+		row := res.Rows[0]
+		info = &models.ColumnInfo{
+			Schema:  row["nspname"].(string),
+			Table:   row["relname"].(string),
+			Column:  row["attname"].(string),
+			NotNull: row["attnotnull"] == "t",
+		}
+	}
+	pg.columns.Store(key, info)
+	return info, nil
+}
@@ -0,0 +1,133 @@
+package protocol
+
+import (
+	"fmt"
+	"postgres-protocol-go/internal/pool"
+	"postgres-protocol-go/pkg/models"
+	"postgres-protocol-go/pkg/types"
+	"strconv"
+)
+
+// ProcessColumnarQuery runs query, with the extended protocol when there are
+// params, and stores its result by column.
+func ProcessColumnarQuery(pgConnection PgConnection, query string, params ...interface{}) (*models.ColumnarResult, error) {
+	var fields []models.Field
+
+	if len(params) > 0 {
+		var err error
+		fields, err = sendExtendedQuery(pgConnection, query, params)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := sendSimpleQuery(pgConnection, query); err != nil {
+		return nil, err
+	}
+
+	queryResult := &models.QueryResult{
+		Fields: fields,
+	}
+
+	var columns []models.Column
+	var row [][]byte
+	rowIdx := 0
+
+	_, err := readQueryResult(pgConnection, queryResult, pool.NewReadBuffer(4096), func(message []byte) error {
+		if columns == nil {
+			columns = newColumns(queryResult.Fields)
+		}
+
+		var err error
+		row, err = parseRawDataRow(message, row)
+		if err != nil {
+			return err
+		}
+		for i, value := range row {
+			if err := appendColumnValue(&columns[i], rowIdx, value); err != nil {
+				return fmt.Errorf("failed to decode column %s: %w", columns[i].Field.Name, err)
+			}
+		}
+		rowIdx++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if columns == nil {
+		columns = newColumns(queryResult.Fields)
+	}
+
+	return &models.ColumnarResult{
+		Command:    queryResult.Command,
+		CommandTag: queryResult.CommandTag,
+		Fields:     queryResult.Fields,
+		RowCount:   queryResult.RowCount,
+		Columns:    columns,
+	}, nil
+}
+
+func newColumns(fields []models.Field) []models.Column {
+	columns := make([]models.Column, len(fields))
+	for i, field := range fields {
+		columns[i].Field = field
+	}
+	return columns
+}
+
+// appendColumnValue appends the value of row rowIdx to the slice of column
+// matching its type, value is nil for NULL.
+func appendColumnValue(column *models.Column, rowIdx int, value []byte) error {
+	format := types.TextFormat
+	if column.Field.Format == "binary" {
+		format = types.BinaryFormat
+	}
+	if value == nil {
+		column.SetNull(rowIdx)
+	}
+
+	switch column.Field.DataTypeOID {
+	case types.Int2OID, types.Int4OID, types.Int8OID:
+		var n int64
+		if value != nil {
+			if err := types.Scan(&n, value, format); err != nil {
+				return err
+			}
+		}
+		column.Int64s = append(column.Int64s, n)
+
+	case types.Float4OID, types.Float8OID, types.NumericOID:
+		var f float64
+		if value != nil {
+			if err := scanColumnFloat64(&f, column.Field.DataTypeOID, value, format); err != nil {
+				return err
+			}
+		}
+		column.Float64s = append(column.Float64s, f)
+
+	case types.BoolOID:
+		var b bool
+		if value != nil {
+			if err := types.Scan(&b, value, format); err != nil {
+				return err
+			}
+		}
+		column.Bools = append(column.Bools, b)
+
+	default:
+		column.Strings = append(column.Strings, string(value))
+	}
+	return nil
+}
+
+func scanColumnFloat64(f *float64, oid uint32, value []byte, format int16) error {
+	if oid != types.NumericOID || format == types.TextFormat {
+		return types.Scan(f, value, format)
+	}
+
+	n, err := types.DecodeNumericBinary(value)
+	if err != nil {
+		return err
+	}
+	*f, err = strconv.ParseFloat(n.String(), 64)
+	return err
+}
@@ -0,0 +1,75 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"postgres-protocol-go/internal/pool"
+	"postgres-protocol-go/internal/protocol/messages"
+	"postgres-protocol-go/pkg/models"
+	"postgres-protocol-go/pkg/utils"
+)
+
+// ProcessDescribe parses query as the unnamed statement without running it
+// and returns the types of its parameters and its result columns. Errors in
+// the query are returned as *models.PgError.
+func ProcessDescribe(pgConnection PgConnection, query string) ([]uint32, []models.Field, error) {
+	buf := pool.NewWriteBuffer(1024)
+	buf.StartMessage(messages.Parse)
+	buf.WriteString("") // unnamed statement
+	buf.WriteString(query)
+	buf.WriteInt16(0) // the server infers the parameter types
+	buf.FinishMessage()
+
+	buf.StartMessage(messages.Describe)
+	buf.WriteByte('S')
+	buf.WriteString("") // unnamed statement
+	buf.FinishMessage()
+
+	messages.WriteSyncMsg(buf)
+
+	err := pgConnection.sendMessage(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var paramOIDs []uint32
+	var fields []models.Field
+	var describeErr error
+
+	for {
+		message, err := pgConnection.readMessage()
+		if err != nil {
+			var pgErr *models.PgError
+			if !errors.As(err, &pgErr) {
+				return nil, nil, err
+			}
+			describeErr = err
+			continue
+		}
+
+		switch utils.ParseIdentifier(message) {
+		case messages.ParameterDescription:
+			paramOIDs = parseParameterDescription(message)
+
+		case messages.RowDescription:
+			fields, err = parseField(message, pgConnection.typeRegistry)
+			if err != nil {
+				describeErr = err
+			}
+
+		case messages.ParseComplete, messages.NoData:
+			continue
+
+		case messages.ReadyForQuery:
+			if describeErr != nil {
+				return nil, nil, describeErr
+			}
+			return paramOIDs, fields, nil
+
+		default:
+			if pgConnection.isVerbose() {
+				fmt.Printf("Describe: Unknown message: %s\n", string(message))
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package protocol
+
+import (
+	"bytes"
+	"postgres-protocol-go/pkg/models"
+	"strconv"
+)
+
+// parseErrorResponse parses the fields of an ErrorResponse body, each a type
+// byte followed by a null-terminated string.
+func parseErrorResponse(body []byte) *models.PgError {
+	pgErr := &models.PgError{}
+
+	for len(body) > 0 && body[0] != 0 {
+		fieldType := body[0]
+		body = body[1:]
+
+		var value string
+		if idx := bytes.IndexByte(body, 0); idx != -1 {
+			value, body = string(body[:idx]), body[idx+1:]
+		} else {
+			value, body = string(body), nil
+		}
+
+		switch fieldType {
+		case 'S':
+			pgErr.Severity = value
+		case 'V':
+			// non-localized severity, sent along with S since 9.6
+			if pgErr.Severity == "" {
+				pgErr.Severity = value
+			}
+		case 'C':
+			pgErr.Code = value
+		case 'M':
+			pgErr.Message = value
+		case 'D':
+			pgErr.Detail = value
+		case 'H':
+			pgErr.Hint = value
+		case 'P':
+			pgErr.Position, _ = strconv.Atoi(value)
+		case 'p':
+			pgErr.InternalPosition, _ = strconv.Atoi(value)
+		case 'q':
+			pgErr.InternalQuery = value
+		case 'W':
+			pgErr.Where = value
+		case 's':
+			pgErr.SchemaName = value
+		case 't':
+			pgErr.TableName = value
+		case 'c':
+			pgErr.ColumnName = value
+		case 'd':
+			pgErr.DataTypeName = value
+		case 'n':
+			pgErr.ConstraintName = value
+		case 'F':
+			pgErr.File = value
+		case 'L':
+			pgErr.Line, _ = strconv.Atoi(value)
+		case 'R':
+			pgErr.Routine = value
+		default:
+			// the server may add field types, they are ignored
+		}
+	}
+
+	return pgErr
+}
@@ -0,0 +1,141 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"postgres-protocol-go/pkg/models"
+	"postgres-protocol-go/pkg/types"
+	"time"
+)
+
+// Exec runs query and returns its command tag, without keeping its rows.
+func (pg *PgConnection) Exec(ctx context.Context, query string, args ...interface{}) (models.CommandTag, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	stop := pg.watchContext(ctx)
+	res, err := ProcessRawQuery(*pg, query, func([]models.Field, [][]byte) error {
+		return nil
+	}, args...)
+	stop()
+
+	if err != nil {
+		return "", contextError(ctx, err)
+	}
+	return res.CommandTag, nil
+}
+
+// Row is the first row of a query run with QueryRow.
+type Row struct {
+	err    error
+	fields []models.Field
+	values [][]byte
+}
+
+// QueryRow runs query and keeps its first row, the other rows are discarded.
+// Errors are returned by Row.Scan.
+func (pg *PgConnection) QueryRow(ctx context.Context, query string, args ...interface{}) *Row {
+	row := &Row{}
+	if err := ctx.Err(); err != nil {
+		row.err = err
+		return row
+	}
+
+	stop := pg.watchContext(ctx)
+	_, err := ProcessRawQuery(*pg, query, func(fields []models.Field, values [][]byte) error {
+		if row.values != nil {
+			return nil
+		}
+		row.fields = fields
+		row.values = make([][]byte, len(values))
+		for i, value := range values {
+			if value != nil {
+				row.values[i] = append([]byte{}, value...)
+			}
+		}
+		return nil
+	}, args...)
+	stop()
+
+	if err != nil {
+		row.err = contextError(ctx, err)
+	}
+	return row
+}
+
+// Scan copies the columns of the row into dest, see types.Scan. A nil dest
+// skips its column. It returns models.ErrNoRows when the query returned no
+// rows.
+func (r *Row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.values == nil {
+		return models.ErrNoRows
+	}
+	if len(dest) != len(r.values) {
+		return fmt.Errorf("expected %d destination arguments in Scan, got %d", len(r.values), len(dest))
+	}
+
+	for i, d := range dest {
+		if d == nil {
+			continue
+		}
+		format := types.TextFormat
+		if r.fields[i].Format == "binary" {
+			format = types.BinaryFormat
+		}
+		if err := types.Scan(d, r.values[i], format); err != nil {
+			return fmt.Errorf("failed to scan column %s: %w", r.fields[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// watchContext cancels the query in progress when ctx is done, until stop
+// is called. The server ends a canceled query with an error and the
+// connection stays usable. When the query can't be canceled, or doesn't end
+// within cancelTimeout, the connection is interrupted and later calls return
+// models.ErrConnBroken.
+func (pg *PgConnection) watchContext(ctx context.Context) (stop func()) {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+		case <-done:
+			return
+		}
+
+		err := pg.sendCancelRequest()
+		if err == nil {
+			select {
+			case <-done:
+				return
+			case <-time.After(cancelTimeout):
+			}
+		} else if pg.isVerbose() {
+			fmt.Printf("Cancel request failed, interrupting the connection: %v\n", err)
+		}
+		pg.interrupt()
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// contextError returns the error of ctx when it caused err.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%w: %w", ctxErr, err)
+	}
+	return err
+}
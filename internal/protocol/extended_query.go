@@ -1,35 +1,60 @@
 package protocol
 
 import (
+	"encoding/binary"
+	"fmt"
 	"postgres-protocol-go/internal/pool"
 	"postgres-protocol-go/internal/protocol/messages"
 	"postgres-protocol-go/pkg/models"
 	"postgres-protocol-go/pkg/types"
+	"postgres-protocol-go/pkg/utils"
+	"reflect"
 )
 
+// encodedParam is a Bind parameter, value is nil for NULL.
+type encodedParam struct {
+	format int16
+	value  []byte
+}
+
 func ProcessExtendedQuery(pgConnection PgConnection, query string, params ...interface{}) (*models.QueryResult, error) {
-	buf := pool.NewWriteBuffer(1024)
-	buf.StartMessage(messages.Parse)
-	buf.WriteString("") // unnamed statement
-	buf.WriteString(query)
-	buf.WriteInt16(0) // don't want to prespecify types for parameters
-	buf.FinishMessage()
+	fields, err := sendExtendedQuery(pgConnection, query, params)
+	if err != nil {
+		return nil, err
+	}
 
-	buf.StartMessage(messages.Describe)
-	buf.WriteByte('S')
-	buf.WriteString("") // unnamed statement
-	buf.FinishMessage()
+	return processQueryResult(pgConnection, fields)
+}
+
+// sendExtendedQuery describes query, then binds params and executes it. It
+// returns the result columns, the result itself is left to be read.
+func sendExtendedQuery(pgConnection PgConnection, query string, params []interface{}) ([]models.Field, error) {
+	params = derefParams(params)
+
+	paramOIDs, fields, err := describeStatement(pgConnection, query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedParams, err := encodeParams(params, paramOIDs)
+	if err != nil {
+		syncAfterError(pgConnection)
+		return nil, err
+	}
 
+	buf := pool.NewWriteBuffer(1024)
 	buf.StartMessage(messages.Bind)
 	buf.WriteString("") // unnamed portal
 	buf.WriteString("") // unnamed statement
-	buf.WriteInt16(0)   // don't want to prespecify types for parameters
-	buf.WriteInt16(int16(len(params)))
-	for _, param := range params {
+	buf.WriteInt16(int16(len(encodedParams)))
+	for _, param := range encodedParams {
+		buf.WriteInt16(param.format)
+	}
+	buf.WriteInt16(int16(len(encodedParams)))
+	for _, param := range encodedParams {
 		buf.StartParam()
-		bytes := types.Append(buf.Bytes, param, 0)
-		if bytes != nil {
-			buf.Bytes = bytes
+		if param.value != nil {
+			buf.Write(param.value)
 			buf.FinishParam()
 		} else {
 			buf.FinishNullParam()
@@ -45,11 +70,168 @@ func ProcessExtendedQuery(pgConnection PgConnection, query string, params ...int
 
 	messages.WriteSyncMsg(buf)
 
-	err := pgConnection.sendMessage(buf)
+	err = pgConnection.sendMessage(buf)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return processQueryResult(pgConnection, query)
+	return fields, nil
+}
+
+// describeStatement parses query as the unnamed statement and returns the
+// parameter types resolved by the server and the result columns. Only typed
+// parameters are declared, see types.ParamOID, and the others too with
+// DriveConfig.PinParamTypes.
+func describeStatement(pgConnection PgConnection, query string, params []interface{}) ([]uint32, []models.Field, error) {
+	buf := pool.NewWriteBuffer(1024)
+	buf.StartMessage(messages.Parse)
+	buf.WriteString("") // unnamed statement
+	buf.WriteString(query)
+	buf.WriteInt16(int16(len(params)))
+	for _, param := range params {
+		oid := types.ParamOID(param)
+		if oid == 0 && pgConnection.driveConfig.PinParamTypes {
+			oid = types.BinaryOID(param)
+		}
+		buf.WriteInt32(int32(oid))
+	}
+	buf.FinishMessage()
+
+	buf.StartMessage(messages.Describe)
+	buf.WriteByte('S')
+	buf.WriteString("") // unnamed statement
+	buf.FinishMessage()
+
+	messages.WriteFlushMsg(buf)
+
+	err := pgConnection.sendMessage(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var paramOIDs []uint32
+
+	for {
+		message, err := pgConnection.readMessage()
+		if err != nil {
+			syncAfterError(pgConnection)
+			return nil, nil, err
+		}
+
+		switch utils.ParseIdentifier(message) {
+		case messages.ParameterDescription:
+			paramOIDs = parseParameterDescription(message)
+
+		case messages.RowDescription:
+			fields, err := parseField(message, pgConnection.typeRegistry)
+			if err != nil {
+				syncAfterError(pgConnection)
+				return nil, nil, err
+			}
+			return paramOIDs, fields, nil
+
+		case messages.NoData:
+			return paramOIDs, nil, nil
+
+		case messages.ParseComplete:
+			continue
+
+		default:
+			if pgConnection.isVerbose() {
+				fmt.Printf("Describe: Unknown message: %s\n", string(message))
+			}
+		}
+	}
+}
+
+// syncAfterError ends the extended query after an error, the server skips
+// messages until Sync and answers it with ReadyForQuery.
+func syncAfterError(pgConnection PgConnection) {
+	buf := pool.NewWriteBuffer(5)
+	messages.WriteSyncMsg(buf)
+	if err := pgConnection.sendMessage(buf); err != nil {
+		return
+	}
+	waitForReady(pgConnection)
+}
+
+func parseParameterDescription(message []byte) []uint32 {
+	numberOfParams := binary.BigEndian.Uint16(message[5:7])
+	idxRead := 7 // Skip header
+
+	oids := make([]uint32, numberOfParams)
+	for i := range oids {
+		oids[i] = binary.BigEndian.Uint32(message[idxRead:])
+		idxRead += 4
+	}
+	return oids
+}
+
+// encodeParams encodes each parameter for the type the server resolved for
+// it, in binary format when there is a binary encoding and in text format
+// otherwise.
+func encodeParams(params []interface{}, paramOIDs []uint32) ([]encodedParam, error) {
+	if len(paramOIDs) != len(params) {
+		return nil, fmt.Errorf("query expects %d parameters, got %d", len(paramOIDs), len(params))
+	}
+
+	encodedParams := make([]encodedParam, len(params))
+
+	for i, param := range params {
+		if typed, ok := param.(types.TypedValue); ok {
+			param = derefParam(typed.Value)
+		}
+		if param == nil {
+			continue
+		}
+
+		value, format, err := types.AppendParam([]byte{}, paramOIDs[i], param)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode parameter $%d: %w", i+1, err)
+		}
+		encodedParams[i] = encodedParam{format: format, value: value}
+	}
+
+	return encodedParams, nil
+}
+
+func derefParams(params []interface{}) []interface{} {
+	derefed := make([]interface{}, len(params))
+	for i, param := range params {
+		derefed[i] = derefParam(param)
+	}
+	return derefed
+}
+
+// isPointerAppender reports whether only the pointer v, and not the value it
+// points to, implements types.ValueAppender.
+func isPointerAppender(v reflect.Value) bool {
+	if _, ok := v.Interface().(types.ValueAppender); !ok {
+		return false
+	}
+	_, ok := v.Elem().Interface().(types.ValueAppender)
+	return !ok
+}
+
+// derefParam follows pointers and returns nil for nil pointers, slices and
+// maps, which are sent as NULL.
+func derefParam(param interface{}) interface{} {
+	if param == nil {
+		return nil
+	}
+	v := reflect.ValueOf(param)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		if isPointerAppender(v) {
+			return v.Interface()
+		}
+		v = v.Elem()
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		return nil
+	}
+	return v.Interface()
 }
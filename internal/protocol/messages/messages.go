@@ -6,6 +6,7 @@ import "postgres-protocol-go/internal/pool"
 const (
 	Startup         = 0 // No identifier
 	SSL             = 0 // No identifier
+	Cancel          = 0 // No identifier
 	Auth            = 'R'
 	SASLInitial     = 'p'
 	SASLResponse    = 'p'
@@ -24,9 +25,21 @@ const (
 	CommandComplete = 'C'
 	Notice          = 'N'
 	Execute         = 'E'
+	Flush           = 'H'
+	BindComplete    = '2'
+	NoData          = 'n'
+	ParameterStatus = 'S'
+	BackendKeyData  = 'K'
+
+	ParameterDescription = 't'
 )
 
 func WriteSyncMsg(buf *pool.WriteBuffer) {
 	buf.StartMessage(Sync)
 	buf.FinishMessage()
 }
+
+func WriteFlushMsg(buf *pool.WriteBuffer) {
+	buf.StartMessage(Flush)
+	buf.FinishMessage()
+}
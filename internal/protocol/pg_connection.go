@@ -1,21 +1,47 @@
 package protocol
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"net"
 	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
 	"postgres-protocol-go/internal/pool"
 	"postgres-protocol-go/internal/protocol/messages"
 	"postgres-protocol-go/pkg/models"
+	"postgres-protocol-go/pkg/types"
 	"postgres-protocol-go/pkg/utils"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+// defaultPort is the port of PostgreSQL, used when none is given.
+const defaultPort = 5432
+
+// maxMessageLength bounds the length of a backend message, larger messages
+// are most likely corrupt: a single value is limited to 1 GB.
+const maxMessageLength = 1<<30 + 1<<20
+
 type PgConnection struct {
-	conn        net.Conn
-	connConfig  models.ConnConfig
-	driveConfig models.DriveConfig
+	conn         net.Conn
+	connConfig   models.ConnConfig
+	driveConfig  models.DriveConfig
+	typeRegistry *types.Registry
+	// columns caches ColumnInfo lookups by table OID and attribute number.
+	columns *sync.Map
+	// serverParams holds the parameters reported by the server at startup.
+	serverParams map[string]string
+	// backendKey identifies the session in CancelRequests.
+	backendKey *backendKey
+	deadline   *connDeadline
 }
 
 func NewPgConnection(connStr string, driveConfig models.DriveConfig) (*PgConnection, error) {
@@ -25,24 +51,179 @@ func NewPgConnection(connStr string, driveConfig models.DriveConfig) (*PgConnect
 		return nil, err
 	}
 
-	url := fmt.Sprintf("%s:%d", connConfig.Host, connConfig.Port)
+	pgConnection, err := connectHosts(connConfig, driveConfig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if driveConfig.LoadTypes {
+		err = pgConnection.LoadTypes()
+	} else {
+		err = loadCompositeTypes(*pgConnection)
+	}
+
+	if err != nil {
+		pgConnection.Close()
+		return nil, err
+	}
+
+	return pgConnection, nil
+}
+
+// connectHosts connects to the first host that accepts the connection and
+// matches target_session_attrs, trying the hosts in random order with
+// load_balance_hosts=random. prefer-standby tries all hosts for a standby
+// before accepting any server.
+func connectHosts(connConfig models.ConnConfig, driveConfig models.DriveConfig) (*PgConnection, error) {
+	order := make([]int, len(connConfig.Hosts))
+	for i := range order {
+		order[i] = i
+	}
+	if connConfig.LoadBalanceHosts == loadBalanceRandom {
+		rand.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+
+	var (
+		errs    []error
+		lastErr error
+	)
+	for _, attrs := range targetSessionPasses(connConfig) {
+		for _, i := range order {
+			hostConfig := connConfig
+			hostConfig.Host, hostConfig.Port = connConfig.Hosts[i], connConfig.Ports[i]
+
+			pgConnection, err := connect(hostConfig, driveConfig)
+			if err == nil {
+				err = checkTargetSession(*pgConnection, attrs)
+				if err == nil {
+					pgConnection.setDeadline(time.Time{})
+					return pgConnection, nil
+				}
+				pgConnection.Close()
+			}
+
+			if driveConfig.Verbose {
+				fmt.Printf("Connection to %s:%d failed: %v\n", hostConfig.Host, hostConfig.Port, err)
+			}
+			lastErr = err
+			errs = append(errs, fmt.Errorf("connection to server at %q, port %d failed: %w", hostConfig.Host, hostConfig.Port, err))
+		}
+	}
+
+	// a single attempt keeps its error as it is
+	if len(errs) == 1 {
+		return nil, lastErr
+	}
+	return nil, errors.Join(errs...)
+}
+
+// connect opens an authenticated connection, negotiating TLS as required by
+// the sslmode: allow retries with TLS when the server rejects the connection,
+// prefer retries without TLS when the server refuses it or the handshake
+// fails. Like libpq, the sslmode is ignored on Unix sockets.
+func connect(connConfig models.ConnConfig, driveConfig models.DriveConfig) (*PgConnection, error) {
+	if isUnixSocket(connConfig.Host) {
+		return dialAndStartup(connConfig, driveConfig, false)
+	}
+
+	if connConfig.SSLNegotiation == sslNegotiationDirect {
+		switch connConfig.SSLMode {
+		case sslModeDisable, sslModeAllow, sslModePrefer:
+			return nil, fmt.Errorf("weak sslmode %q may not be used with sslnegotiation=direct (use require, verify-ca, or verify-full)", connConfig.SSLMode)
+		}
+	}
+
+	switch connConfig.SSLMode {
+	case sslModeDisable:
+		return dialAndStartup(connConfig, driveConfig, false)
+
+	case sslModeAllow:
+		pgConnection, err := dialAndStartup(connConfig, driveConfig, false)
+		var pgErr *models.PgError
+		if errors.As(err, &pgErr) {
+			if driveConfig.Verbose {
+				fmt.Printf("Connection rejected, retrying with SSL: %v\n", err)
+			}
+			return dialAndStartup(connConfig, driveConfig, true)
+		}
+		return pgConnection, err
+
+	case sslModePrefer:
+		pgConnection, err := dialAndStartup(connConfig, driveConfig, true)
+		var sslErr *sslError
+		if errors.As(err, &sslErr) {
+			if driveConfig.Verbose {
+				fmt.Printf("SSL failed, retrying without SSL: %v\n", err)
+			}
+			return dialAndStartup(connConfig, driveConfig, false)
+		}
+		return pgConnection, err
+	}
+
+	return dialAndStartup(connConfig, driveConfig, true)
+}
+
+// dialAndStartup opens a connection, with TLS when useSSL is true, and
+// authenticates.
+func dialAndStartup(connConfig models.ConnConfig, driveConfig models.DriveConfig, useSSL bool) (*PgConnection, error) {
+	if connConfig.Password == nil || *connConfig.Password == "" {
+		if password, ok := passwordFromFile(connConfig); ok {
+			connConfig.Password = &password
+		}
+	}
+
+	network, address := "tcp", net.JoinHostPort(connConfig.Host, strconv.Itoa(connConfig.Port))
+	if isUnixSocket(connConfig.Host) {
+		network, address = "unix", socketPath(connConfig.Host, connConfig.Port)
+	}
 
 	if driveConfig.Verbose {
-		fmt.Printf("Connecting to PostgreSQL at %s\n", url)
+		fmt.Printf("Connecting to PostgreSQL at %s\n", address)
 	}
 
-	conn, err := net.Dial("tcp", url)
+	// connect_timeout bounds the whole attempt, not only the dial
+	var deadline time.Time
+	if connConfig.ConnectTimeout > 0 {
+		deadline = time.Now().Add(connConfig.ConnectTimeout)
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.Dial(network, address)
 
 	if err != nil {
+		if network == "unix" {
+			return nil, fmt.Errorf("failed to connect to the PostgreSQL Unix socket %s: %w", address, err)
+		}
 		return nil, fmt.Errorf("failed to establish a TCP connection to PostgreSQL: %w", err)
 	}
 
-	pgConnection := PgConnection{conn: conn, connConfig: connConfig, driveConfig: driveConfig}
+	pgConnection := PgConnection{
+		conn:         conn,
+		connConfig:   connConfig,
+		driveConfig:  driveConfig,
+		typeRegistry: types.NewRegistry(),
+		columns:      &sync.Map{},
+		serverParams: map[string]string{},
+		backendKey:   &backendKey{},
+		deadline:     &connDeadline{},
+	}
+	pgConnection.setDeadline(deadline)
+
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		if err := setTCPOptions(tcpConn, connConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to set TCP options: %w", err)
+		}
+	}
 
-	if connConfig.Secure {
-		err = ProcessSSL(&pgConnection)
+	if useSSL {
+		if connConfig.SSLNegotiation == sslNegotiationDirect {
+			err = ProcessDirectSSL(&pgConnection)
+		} else {
+			err = ProcessSSL(&pgConnection)
+		}
 		if err != nil {
-			pgConnection.Close()
+			pgConnection.conn.Close()
 			return nil, err
 		}
 	}
@@ -67,13 +248,41 @@ func (pg *PgConnection) Query(query string, params ...interface{}) (*models.Quer
 	return ProcessSimpleQuery(*pg, query)
 }
 
+// QueryRaw runs query and calls fn with the undecoded column values of each
+// row, see RawRowFunc.
+func (pg *PgConnection) QueryRaw(query string, fn RawRowFunc, params ...interface{}) (*models.QueryResult, error) {
+	return ProcessRawQuery(*pg, query, fn, params...)
+}
+
+// QueryColumnar runs query like Query but stores its result by column.
+func (pg *PgConnection) QueryColumnar(query string, params ...interface{}) (*models.ColumnarResult, error) {
+	return ProcessColumnarQuery(*pg, query, params...)
+}
+
+// Describe returns the parameter types and result columns of query without
+// running it. Errors in the query are returned as *models.PgError, with the
+// position of the error when the server reports one.
+func (pg *PgConnection) Describe(query string) ([]uint32, []models.Field, error) {
+	return ProcessDescribe(*pg, query)
+}
+
+// TypeRegistry returns the types resolved on this connection.
+func (pg *PgConnection) TypeRegistry() *types.Registry {
+	return pg.typeRegistry
+}
+
 func (pg *PgConnection) sendMessage(buf *pool.WriteBuffer) error {
+	if pg.isInterrupted() {
+		return models.ErrConnBroken
+	}
+
 	message := buf.Bytes
 
 	if pg.isVerbose() {
 		utils.LogFrontendRequest(message)
 	}
 
+	pg.setWriteDeadline()
 	_, err := pg.conn.Write(message)
 	if err != nil {
 		return fmt.Errorf("error sending message: %w", err)
@@ -82,27 +291,42 @@ func (pg *PgConnection) sendMessage(buf *pool.WriteBuffer) error {
 }
 
 func (pg *PgConnection) readMessage() ([]byte, error) {
-	header := make([]byte, 5)
-	_, err := pg.conn.Read(header)
+	return pg.readMessageInto(nil)
+}
+
+// readMessageInto reads a message into buf, which is reused, or into a new
+// slice when buf is nil.
+func (pg *PgConnection) readMessageInto(buf *pool.ReadBuffer) ([]byte, error) {
+	if buf == nil {
+		buf = &pool.ReadBuffer{}
+	}
+
+	pg.setReadDeadline()
+	header := buf.Grow(5)
+	_, err := io.ReadFull(pg.conn, header)
 	if err != nil {
 		return nil, fmt.Errorf("error reading from connection: %w", err)
 	}
 
 	identifier := utils.ParseIdentifier(header)
 	messageLength := utils.ParseMessageLength(header)
+	if messageLength < 4 || messageLength > maxMessageLength {
+		// the next message can't be found
+		pg.interrupt()
+		return nil, fmt.Errorf("invalid length %d of message %q", messageLength, identifier)
+	}
 
 	remaining := messageLength - 4
 
-	message := make([]byte, remaining)
-	_, err = pg.conn.Read(message)
+	fullMessage := buf.Grow(5 + int(remaining))
+	message := fullMessage[5:]
+	_, err = io.ReadFull(pg.conn, message)
 	if err != nil {
 		return nil, fmt.Errorf("error reading from connection: %w", err)
 	}
 
-	fullMessage := append(header, message...)
-
 	if identifier == messages.Error {
-		return nil, fmt.Errorf("error from backend: %s", utils.ParseBackendErrorMessage(message))
+		return nil, parseErrorResponse(message)
 	}
 
 	if pg.isVerbose() {
@@ -114,6 +338,7 @@ func (pg *PgConnection) readMessage() ([]byte, error) {
 
 func (pg *PgConnection) readSingleByteMessage() ([]byte, error) {
 	message := make([]byte, 1)
+	pg.setReadDeadline()
 	_, err := pg.conn.Read(message)
 	if err != nil {
 		return nil, fmt.Errorf("error reading from connection: %w", err)
@@ -140,74 +365,436 @@ func (pg *PgConnection) isVerbose() bool {
 }
 
 func parseConnStr(connUrl string) (models.ConnConfig, error) {
-	connConfig := models.ConnConfig{}
+	connConfig := models.ConnConfig{
+		Keepalives:         true,
+		LoadBalanceHosts:   loadBalanceDisable,
+		TargetSessionAttrs: targetSessionAny,
+		SSLMode:            sslModePrefer,
+		SSLSNI:             true,
+		SSLNegotiation:     sslNegotiationPostgres,
+		ChannelBinding:     channelBindingPrefer,
+	}
+
+	params, err := parseConnParams(connUrl)
+	if err != nil {
+		return connConfig, err
+	}
+
+	for _, envParam := range envParams {
+		if _, ok := params[envParam.param]; ok {
+			continue
+		}
+		if value := os.Getenv(envParam.env); value != "" {
+			params[envParam.param] = value
+		}
+	}
 
-	if strings.HasPrefix(connUrl, "postgres://") {
-		parsedUrl, err := url.Parse(connUrl)
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		err := setConnParam(&connConfig, key, params[key])
 		if err != nil {
-			return connConfig, fmt.Errorf("failed to parse connection URL: %w", err)
+			return connConfig, err
 		}
-		connConfig.Host = parsedUrl.Hostname()
-		port := parsedUrl.Port()
-		if port != "" {
-			portInt, err := strconv.Atoi(port)
-			if err == nil {
-				connConfig.Port = portInt
+	}
+
+	err = setConnDefaults(&connConfig)
+	return connConfig, err
+}
+
+// parseConnParams returns the parameters of a URL or a key=value connection
+// string.
+func parseConnParams(connUrl string) (map[string]string, error) {
+	for _, scheme := range []string{"postgres://", "postgresql://"} {
+		if rest, ok := strings.CutPrefix(connUrl, scheme); ok {
+			return parseURLParams(rest)
+		}
+	}
+
+	return parseKeyValueParams(connUrl)
+}
+
+// parseURLParams returns the parameters of a connection URL without its
+// scheme. The authority is split by hand, as net/url does not accept a
+// comma-separated list of hosts with ports.
+func parseURLParams(rest string) (map[string]string, error) {
+	params := map[string]string{}
+
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, query, _ := strings.Cut(rest, "?")
+	authority, path, _ := strings.Cut(rest, "/")
+
+	unescape := func(s string) (string, error) {
+		value, err := url.PathUnescape(s)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse connection URL: %w", err)
+		}
+		return value, nil
+	}
+
+	if i := strings.LastIndex(authority, "@"); i >= 0 {
+		userInfo := authority[:i]
+		authority = authority[i+1:]
+
+		user, password, hasPassword := strings.Cut(userInfo, ":")
+		user, err := unescape(user)
+		if err != nil {
+			return nil, err
+		}
+		if user != "" {
+			params["user"] = user
+		}
+		if hasPassword {
+			if params["password"], err = unescape(password); err != nil {
+				return nil, err
 			}
 		}
-		connConfig.User = parsedUrl.User.Username()
-		if password, ok := parsedUrl.User.Password(); ok {
-			connConfig.Password = &password
+	}
+
+	if authority != "" {
+		var hosts, ports []string
+		for _, hostPort := range strings.Split(authority, ",") {
+			host, port := hostPort, ""
+			if strings.HasPrefix(host, "[") {
+				end := strings.Index(host, "]")
+				if end < 0 {
+					return nil, fmt.Errorf("failed to parse connection URL: missing \"]\" in host %q", hostPort)
+				}
+				host, port = host[1:end], strings.TrimPrefix(host[end+1:], ":")
+			} else if i := strings.LastIndex(host, ":"); i >= 0 {
+				host, port = host[:i], host[i+1:]
+			}
+			host, err := unescape(host)
+			if err != nil {
+				return nil, err
+			}
+			hosts = append(hosts, host)
+			ports = append(ports, port)
 		}
-		path := strings.TrimPrefix(parsedUrl.Path, "/")
-		if path != "" {
-			connConfig.Database = &path
+		params["host"] = strings.Join(hosts, ",")
+		if strings.Join(ports, "") != "" {
+			params["port"] = strings.Join(ports, ",")
 		}
-		if parsedUrl.Query().Get("sslmode") == "require" {
-			connConfig.Secure = true
+	}
+
+	if path != "" {
+		database, err := unescape(path)
+		if err != nil {
+			return nil, err
 		}
-		return connConfig, nil
+		params["dbname"] = database
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection URL: %w", err)
 	}
+	for key, value := range values {
+		params[key] = value[len(value)-1]
+	}
+	return params, nil
+}
 
-	split := strings.Fields(connUrl)
+// parseKeyValueParams parses a key=value connection string like libpq:
+// spaces around = are allowed, and values may be single-quoted, with \
+// escaping quotes and backslashes.
+func parseKeyValueParams(connStr string) (map[string]string, error) {
+	params := map[string]string{}
+	isSpace := func(c byte) bool {
+		return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+	}
 
-	for _, s := range split {
-		if strings.HasPrefix(s, "host=") {
-			host := strings.SplitN(s, "=", 2)[1]
-			connConfig.Host = host
-			continue
+	i := 0
+	for {
+		for i < len(connStr) && isSpace(connStr[i]) {
+			i++
 		}
-		if strings.HasPrefix(s, "port=") {
-			portStr := strings.SplitN(s, "=", 2)[1]
-			port, err := strconv.Atoi(portStr)
-			if err == nil {
-				connConfig.Port = port
+		if i == len(connStr) {
+			return params, nil
+		}
+
+		start := i
+		for i < len(connStr) && connStr[i] != '=' && !isSpace(connStr[i]) {
+			i++
+		}
+		key := connStr[start:i]
+		for i < len(connStr) && isSpace(connStr[i]) {
+			i++
+		}
+		if i == len(connStr) || connStr[i] != '=' {
+			return nil, fmt.Errorf("missing \"=\" after %q in connection info string", key)
+		}
+		i++
+		for i < len(connStr) && isSpace(connStr[i]) {
+			i++
+		}
+
+		var value strings.Builder
+		if i < len(connStr) && connStr[i] == '\'' {
+			i++
+			for {
+				if i == len(connStr) {
+					return nil, fmt.Errorf("unterminated quoted string in connection info string")
+				}
+				if connStr[i] == '\\' && i+1 < len(connStr) {
+					i++
+				} else if connStr[i] == '\'' {
+					i++
+					break
+				}
+				value.WriteByte(connStr[i])
+				i++
+			}
+		} else {
+			for i < len(connStr) && !isSpace(connStr[i]) {
+				if connStr[i] == '\\' && i+1 < len(connStr) {
+					i++
+				}
+				value.WriteByte(connStr[i])
+				i++
 			}
-			continue
 		}
-		if strings.HasPrefix(s, "user=") {
-			user := strings.SplitN(s, "=", 2)[1]
-			connConfig.User = user
-			continue
+		params[key] = value.String()
+	}
+}
+
+// envParams are the environment variables of libpq that set parameters
+// missing from the connection string.
+var envParams = []struct {
+	env   string
+	param string
+}{
+	{"PGHOST", "host"},
+	{"PGPORT", "port"},
+	{"PGUSER", "user"},
+	{"PGDATABASE", "dbname"},
+	{"PGPASSWORD", "password"},
+	{"PGSSLMODE", "sslmode"},
+	{"PGAPPNAME", "application_name"},
+	{"PGOPTIONS", "options"},
+	{"PGCONNECT_TIMEOUT", "connect_timeout"},
+	{"PGLOADBALANCEHOSTS", "load_balance_hosts"},
+	{"PGTARGETSESSIONATTRS", "target_session_attrs"},
+}
+
+// setConnDefaults applies the defaults of libpq: port 5432, the Unix socket
+// of the server or localhost, the operating system user and a database named
+// after the user. A single port applies to all hosts.
+func setConnDefaults(connConfig *models.ConnConfig) error {
+	if len(connConfig.Hosts) == 0 {
+		connConfig.Hosts = []string{""}
+	}
+	if len(connConfig.Ports) == 0 {
+		connConfig.Ports = []int{0}
+	}
+	if len(connConfig.Ports) == 1 && len(connConfig.Hosts) > 1 {
+		port := connConfig.Ports[0]
+		connConfig.Ports = make([]int, len(connConfig.Hosts))
+		for i := range connConfig.Ports {
+			connConfig.Ports[i] = port
 		}
-		if strings.HasPrefix(s, "dbname=") {
-			dbname := strings.SplitN(s, "=", 2)[1]
-			connConfig.Database = &dbname
-			continue
+	}
+	if len(connConfig.Ports) != len(connConfig.Hosts) {
+		return fmt.Errorf("could not match %d port numbers to %d hosts", len(connConfig.Ports), len(connConfig.Hosts))
+	}
+
+	for i := range connConfig.Hosts {
+		if connConfig.Ports[i] == 0 {
+			connConfig.Ports[i] = defaultPort
 		}
-		if strings.HasPrefix(s, "password=") {
-			password := strings.SplitN(s, "=", 2)[1]
-			connConfig.Password = &password
-			continue
+		if connConfig.Hosts[i] == "" {
+			connConfig.Hosts[i] = defaultHost(connConfig.Ports[i])
 		}
-		if strings.HasPrefix(s, "sslmode=") {
-			sslmode := strings.SplitN(s, "=", 2)[1]
-			if sslmode == "require" {
-				connConfig.Secure = true
+	}
+	connConfig.Host, connConfig.Port = connConfig.Hosts[0], connConfig.Ports[0]
+
+	if connConfig.User == "" {
+		connConfig.User = defaultUser()
+	}
+	if connConfig.Database == nil || *connConfig.Database == "" {
+		database := connConfig.User
+		connConfig.Database = &database
+	}
+	return nil
+}
+
+// defaultSocketDirs are the directories PostgreSQL creates its socket in by
+// default, on Debian and upstream.
+var defaultSocketDirs = []string{"/var/run/postgresql", "/tmp"}
+
+// defaultHost returns the first default socket directory where a server
+// listens on port, or localhost.
+func defaultHost(port int) string {
+	if runtime.GOOS != "windows" {
+		for _, dir := range defaultSocketDirs {
+			if _, err := os.Stat(socketPath(dir, port)); err == nil {
+				return dir
 			}
-			continue
 		}
 	}
+	return "localhost"
+}
+
+// isUnixSocket reports whether host is the directory of a Unix socket, or
+// an abstract socket namespace on Linux when it starts with @.
+func isUnixSocket(host string) bool {
+	return strings.HasPrefix(host, "/") || strings.HasPrefix(host, "@")
+}
+
+// socketPath returns the path of the Unix socket of a server listening on
+// port in dir.
+func socketPath(dir string, port int) string {
+	return filepath.Join(dir, ".s.PGSQL."+strconv.Itoa(port))
+}
+
+func defaultUser() string {
+	current, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	// on Windows the user name is qualified with the domain
+	if i := strings.LastIndex(current.Username, `\`); i >= 0 {
+		return current.Username[i+1:]
+	}
+	return current.Username
+}
 
-	return connConfig, nil
+// setConnParam sets a connection parameter, unknown parameters are ignored.
+func setConnParam(connConfig *models.ConnConfig, key, value string) error {
+	switch key {
+	case "host":
+		connConfig.Hosts = strings.Split(value, ",")
+	case "port":
+		connConfig.Ports = nil
+		for _, port := range strings.Split(value, ",") {
+			number := 0
+			if port != "" {
+				var err error
+				number, err = strconv.Atoi(port)
+				if err != nil || number < 1 || number > 65535 {
+					return fmt.Errorf("invalid port number: %q", port)
+				}
+			}
+			connConfig.Ports = append(connConfig.Ports, number)
+		}
+	case "user":
+		connConfig.User = value
+	case "dbname":
+		connConfig.Database = &value
+	case "password":
+		connConfig.Password = &value
+	case "passfile":
+		connConfig.PassFile = value
+	case "application_name":
+		connConfig.ApplicationName = value
+	case "options":
+		connConfig.Options = value
+	case "connect_timeout":
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid connect_timeout value: %q", value)
+		}
+		// like libpq, a timeout of one second is rounded up to two
+		if seconds == 1 {
+			seconds = 2
+		}
+		connConfig.ConnectTimeout = time.Duration(max(seconds, 0)) * time.Second
+	case "read_timeout", "write_timeout":
+		timeout, err := parseTimeout(key, value)
+		if err != nil {
+			return err
+		}
+		if key == "read_timeout" {
+			connConfig.ReadTimeout = timeout
+		} else {
+			connConfig.WriteTimeout = timeout
+		}
+	case "keepalives":
+		switch value {
+		case "0":
+			connConfig.Keepalives = false
+		case "1":
+			connConfig.Keepalives = true
+		default:
+			return fmt.Errorf("invalid keepalives value: %q", value)
+		}
+	case "keepalives_idle", "keepalives_interval":
+		interval, err := parseSeconds(key, value)
+		if err != nil {
+			return err
+		}
+		if key == "keepalives_idle" {
+			connConfig.KeepalivesIdle = interval
+		} else {
+			connConfig.KeepalivesInterval = interval
+		}
+	case "keepalives_count":
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 0 {
+			return fmt.Errorf("invalid keepalives_count value: %q", value)
+		}
+		connConfig.KeepalivesCount = count
+	case "tcp_user_timeout":
+		milliseconds, err := strconv.Atoi(value)
+		if err != nil || milliseconds < 0 {
+			return fmt.Errorf("invalid tcp_user_timeout value: %q", value)
+		}
+		connConfig.TCPUserTimeout = time.Duration(milliseconds) * time.Millisecond
+	case "load_balance_hosts":
+		switch value {
+		case loadBalanceDisable, loadBalanceRandom:
+			connConfig.LoadBalanceHosts = value
+		default:
+			return fmt.Errorf("invalid load_balance_hosts value: %q", value)
+		}
+	case "target_session_attrs":
+		if !isValidTargetSession(value) {
+			return fmt.Errorf("invalid target_session_attrs value: %q", value)
+		}
+		connConfig.TargetSessionAttrs = value
+	case "sslmode":
+		switch value {
+		case sslModeDisable, sslModeAllow, sslModePrefer, sslModeRequire, sslModeVerifyCA, sslModeVerifyFull:
+			connConfig.SSLMode = value
+		default:
+			return fmt.Errorf("invalid sslmode value: %q", value)
+		}
+	case "sslrootcert":
+		connConfig.SSLRootCert = value
+	case "sslcert":
+		connConfig.SSLCert = value
+	case "sslkey":
+		connConfig.SSLKey = value
+	case "sslpassword":
+		connConfig.SSLPassword = value
+	case "sslcrl":
+		connConfig.SSLCRL = value
+	case "sslsni":
+		switch value {
+		case "0":
+			connConfig.SSLSNI = false
+		case "1":
+			connConfig.SSLSNI = true
+		default:
+			return fmt.Errorf("invalid sslsni value: %q", value)
+		}
+	case "sslnegotiation":
+		switch value {
+		case sslNegotiationPostgres, sslNegotiationDirect:
+			connConfig.SSLNegotiation = value
+		default:
+			return fmt.Errorf("invalid sslnegotiation value: %q", value)
+		}
+	case "channel_binding":
+		switch value {
+		case channelBindingDisable, channelBindingPrefer, channelBindingRequire:
+			connConfig.ChannelBinding = value
+		default:
+			return fmt.Errorf("invalid channel_binding value: %q", value)
+		}
+	}
+	return nil
 }
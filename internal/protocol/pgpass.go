@@ -0,0 +1,137 @@
+package protocol
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"postgres-protocol-go/pkg/models"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// passFile returns the password file of connConfig: passfile, PGPASSFILE or
+// ~/.pgpass, %APPDATA%\postgresql\pgpass.conf on Windows.
+func passFile(connConfig models.ConnConfig) string {
+	if connConfig.PassFile != "" {
+		return connConfig.PassFile
+	}
+	if path := os.Getenv("PGPASSFILE"); path != "" {
+		return path
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "postgresql", "pgpass.conf")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".pgpass")
+}
+
+// passwordFromFile looks up the password of connConfig in its password file,
+// see https://www.postgresql.org/docs/current/libpq-pgpass.html. Like libpq,
+// a file that is readable by the group or others is ignored with a warning.
+func passwordFromFile(connConfig models.ConnConfig) (string, bool) {
+	path := passFile(connConfig)
+	if path == "" {
+		return "", false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	if !info.Mode().IsRegular() {
+		fmt.Fprintf(os.Stderr, "WARNING: password file %q is not a plain file\n", path)
+		return "", false
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: password file %q has group or world access; permissions should be u=rw (0600) or less\n", path)
+		return "", false
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	// A host that is empty or a socket directory matches localhost.
+	host := connConfig.Host
+	if host == "" || isUnixSocket(host) {
+		host = "localhost"
+	}
+	port := connConfig.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	database := connConfig.User
+	if connConfig.Database != nil {
+		database = *connConfig.Database
+	}
+	fields := []string{host, strconv.Itoa(port), database, connConfig.User}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if password, ok := matchPassLine(line, fields); ok {
+			return password, true
+		}
+	}
+	return "", false
+}
+
+// matchPassLine returns the password of a host:port:database:user:password
+// line when its first fields match fields. A field of * matches anything,
+// and \ escapes : and \ in the fields and the password.
+func matchPassLine(line string, fields []string) (string, bool) {
+	rest := line
+	for _, field := range fields {
+		var ok bool
+		rest, ok = matchPassField(rest, field)
+		if !ok {
+			return "", false
+		}
+	}
+
+	var password strings.Builder
+	for i := 0; i < len(rest) && rest[i] != ':'; i++ {
+		if rest[i] == '\\' && i+1 < len(rest) {
+			i++
+		}
+		password.WriteByte(rest[i])
+	}
+	return password.String(), true
+}
+
+// matchPassField matches the first field of line with value and returns the
+// rest of line after its colon.
+func matchPassField(line, value string) (string, bool) {
+	if strings.HasPrefix(line, "*:") {
+		return line[2:], true
+	}
+
+	j := 0
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		escaped := false
+		if c == '\\' && i+1 < len(line) {
+			i++
+			c = line[i]
+			escaped = true
+		}
+		if c == ':' && !escaped {
+			return line[i+1:], j == len(value)
+		}
+		if j == len(value) || c != value[j] {
+			return "", false
+		}
+		j++
+	}
+	return "", false
+}
@@ -2,52 +2,92 @@ package protocol
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"postgres-protocol-go/internal/pool"
 	"postgres-protocol-go/internal/protocol/messages"
 	"postgres-protocol-go/pkg/models"
+	"postgres-protocol-go/pkg/types"
 	"postgres-protocol-go/pkg/utils"
-	"strings"
 )
 
-func processQueryResult(pgConnection PgConnection, query string) (*models.QueryResult, error) {
+// processQueryResult reads the result of a query up to ReadyForQuery. Only the
+// first statement of a multi-statement query is returned. fields are the
+// columns already described for an extended query, nil otherwise.
+func processQueryResult(pgConnection PgConnection, fields []models.Field) (*models.QueryResult, error) {
 	queryResult := &models.QueryResult{
-		Command: strings.Fields(query)[0], // First word of the query
-		Rows:    make([]map[string]interface{}, 0),
+		Fields: fields,
+		Rows:   make([]map[string]interface{}, 0),
+		Values: make([][]any, 0),
 	}
 
-	var fields []models.Field
+	return readQueryResult(pgConnection, queryResult, nil, func(message []byte) error {
+		values, err := parseDataRow(message, queryResult.Fields, pgConnection.typeRegistry)
+		if err != nil {
+			return err
+		}
+		queryResult.Values = append(queryResult.Values, values)
+		queryResult.Rows = append(queryResult.Rows, rowMap(queryResult.Fields, values))
+		return nil
+	})
+}
+
+// readQueryResult reads the result of a query into queryResult up to
+// ReadyForQuery and calls onRow with each DataRow of the first statement.
+// Messages are read into buf when it is not nil, so a DataRow is only valid
+// until onRow returns.
+func readQueryResult(pgConnection PgConnection, queryResult *models.QueryResult, buf *pool.ReadBuffer, onRow func(message []byte) error) (*models.QueryResult, error) {
+	var queryErr error
+	completed := false
+	rowCount := 0
 
 	for {
-		message, err := pgConnection.readMessage()
+		message, err := pgConnection.readMessageInto(buf)
 		if err != nil {
-			return nil, err
+			var pgErr *models.PgError
+			if !errors.As(err, &pgErr) {
+				return nil, err
+			}
+			// the server sends ReadyForQuery after the error
+			if queryErr == nil {
+				queryErr = err
+			}
+			continue
 		}
 
 		identifier := utils.ParseIdentifier(message)
 
 		switch identifier {
 		case messages.RowDescription:
-			fields, err = parseField(message)
+			if completed {
+				continue
+			}
+			fields, err := parseField(message, pgConnection.typeRegistry)
 			if err != nil {
 				return nil, err
 			}
 			queryResult.Fields = fields
 
 		case messages.DataRow:
-			row := parseDataRow(message, fields)
-			queryResult.Rows = append(queryResult.Rows, row)
+			if completed || queryErr != nil {
+				continue
+			}
+			if err := onRow(message); err != nil {
+				queryErr = err
+				continue
+			}
+			rowCount++
 
 		case messages.CommandComplete:
-			queryResult.RowCount = len(queryResult.Rows)
-
-			return queryResult, nil
-
-		case messages.Error:
-			identifierFieldType := string(message[5:6])
-			if identifierFieldType == "0" {
-				return nil, fmt.Errorf("PostgreSQL error: %s", utils.ParseNullTerminatedString(message[6:]))
+			if !completed {
+				queryResult.CommandTag = models.CommandTag(utils.ParseNullTerminatedString(message[5:]))
+				queryResult.Command = queryResult.CommandTag.Command()
+				queryResult.RowCount = rowCount
+				if n := queryResult.RowsAffected(); n > 0 {
+					queryResult.RowCount = int(n)
+				}
+				completed = true
 			}
-			return nil, fmt.Errorf("PostgreSQL error: %s: %s", identifierFieldType, utils.ParseNullTerminatedString(message[6:]))
 
 		case messages.Notice:
 			identifierFieldType := string(message[5:6])
@@ -62,7 +102,10 @@ func processQueryResult(pgConnection PgConnection, query string) (*models.QueryR
 			fmt.Printf("PostgreSQL notice: %s: %s", identifierFieldType, utils.ParseNullTerminatedString(message[6:]))
 
 		case messages.ReadyForQuery:
-			continue
+			if queryErr != nil {
+				return nil, queryErr
+			}
+			return queryResult, nil
 
 		default:
 			if pgConnection.isVerbose() {
@@ -72,38 +115,106 @@ func processQueryResult(pgConnection PgConnection, query string) (*models.QueryR
 	}
 }
 
-func parseDataRow(answer []byte, fields []models.Field) map[string]interface{} {
-	row := make(map[string]interface{})
+// parseDataRow returns the column values of a DataRow in the order of fields.
+func parseDataRow(answer []byte, fields []models.Field, registry *types.Registry) ([]any, error) {
+	values := make([]any, len(fields))
 	idxRead := 7 // Skip Header
 
-	for _, field := range fields {
-		value := parseColumnValue(answer, field, idxRead)
-		row[field.Name] = value
+	for i, field := range fields {
+		value, next, err := parseColumnValue(answer, field, idxRead, registry)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+		idxRead = next
 	}
-	return row
+	return values, nil
 }
 
-func parseColumnValue(answer []byte, field models.Field, idxRead int) any {
-	columnValueLength := int32(binary.BigEndian.Uint32(answer[idxRead:]))
-	idxRead += 4
+// rowMap maps column names to values, the last of duplicate names wins.
+func rowMap(fields []models.Field, values []any) map[string]interface{} {
+	row := make(map[string]interface{}, len(fields))
+	for i, field := range fields {
+		row[field.Name] = values[i]
+	}
+	return row
+}
 
-	if columnValueLength == -1 {
-		return nil
+// parseColumnValue returns the value of the column starting at idxRead and the
+// index of the next column. Types found in the registry are decoded, any other
+// value is returned as a string or raw bytes depending on the field format.
+func parseColumnValue(answer []byte, field models.Field, idxRead int, registry *types.Registry) (any, int, error) {
+	value, next, err := readColumn(answer, idxRead)
+	if err != nil {
+		return nil, next, err
+	}
+	if value == nil {
+		return nil, next, nil
 	}
 
-	value := answer[idxRead : idxRead+int(columnValueLength)]
+	if registry != nil {
+		format := types.TextFormat
+		if field.Format == "binary" {
+			format = types.BinaryFormat
+		}
+		decoded, ok, err := registry.Decode(field.DataTypeOID, value, format)
+		if err != nil {
+			return nil, next, fmt.Errorf("failed to decode column %s: %w", field.Name, err)
+		}
+		if ok {
+			return decoded, next, nil
+		}
+	}
 
 	switch field.Format {
 	case "text":
-		return string(value)
+		return string(value), next, nil
 	case "binary":
-		return value
+		return value, next, nil
 	}
 
-	return nil
+	return nil, next, nil
+}
+
+// readColumn returns the value of the DataRow column starting at idxRead, nil
+// for NULL, and the index of the next column.
+func readColumn(answer []byte, idxRead int) ([]byte, int, error) {
+	if len(answer) < idxRead+4 {
+		return nil, idxRead, fmt.Errorf("truncated DataRow message")
+	}
+	columnValueLength := int32(binary.BigEndian.Uint32(answer[idxRead:]))
+	idxRead += 4
+
+	if columnValueLength == -1 {
+		return nil, idxRead, nil
+	}
+
+	next := idxRead + int(columnValueLength)
+	if columnValueLength < 0 || len(answer) < next {
+		return nil, idxRead, fmt.Errorf("truncated DataRow message")
+	}
+	return answer[idxRead:next:next], next, nil
+}
+
+// parseRawDataRow slices the column values of a DataRow into row, reusing its
+// memory. NULL values are nil.
+func parseRawDataRow(answer []byte, row [][]byte) ([][]byte, error) {
+	numberOfColumns := int(binary.BigEndian.Uint16(answer[5:7]))
+	idxRead := 7 // Skip Header
+
+	row = row[:0]
+	for i := 0; i < numberOfColumns; i++ {
+		value, next, err := readColumn(answer, idxRead)
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, value)
+		idxRead = next
+	}
+	return row, nil
 }
 
-func parseField(answer []byte) ([]models.Field, error) {
+func parseField(answer []byte, registry *types.Registry) ([]models.Field, error) {
 	identifier := utils.ParseIdentifier(answer)
 	if identifier != messages.RowDescription {
 		return nil, fmt.Errorf("expected RowDescription message, got %s", utils.ParseIdentifierStr(answer))
@@ -147,6 +258,7 @@ func parseField(answer []byte) ([]models.Field, error) {
 			TableOID:     tableOID,
 			AttrNum:      columnAttrNum,
 			DataTypeOID:  dataTypeOID,
+			DataTypeName: registry.TypeName(dataTypeOID, int32(typeModifier)),
 			Size:         dataTypeSize,
 			TypeModifier: typeModifier,
 			Format:       format,
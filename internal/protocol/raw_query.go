@@ -0,0 +1,43 @@
+package protocol
+
+import (
+	"postgres-protocol-go/internal/pool"
+	"postgres-protocol-go/pkg/models"
+)
+
+// RawRowFunc is called with the raw column values of each row, nil for NULL.
+// row and its values point into the read buffer and are only valid until the
+// function returns.
+type RawRowFunc func(fields []models.Field, row [][]byte) error
+
+// ProcessRawQuery runs query, with the extended protocol when there are
+// params, and calls fn with each row without decoding it. The returned result
+// has no rows. When fn returns an error the remaining rows are skipped and the
+// error is returned.
+func ProcessRawQuery(pgConnection PgConnection, query string, fn RawRowFunc, params ...interface{}) (*models.QueryResult, error) {
+	var fields []models.Field
+
+	if len(params) > 0 {
+		var err error
+		fields, err = sendExtendedQuery(pgConnection, query, params)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := sendSimpleQuery(pgConnection, query); err != nil {
+		return nil, err
+	}
+
+	queryResult := &models.QueryResult{
+		Fields: fields,
+	}
+
+	var row [][]byte
+	return readQueryResult(pgConnection, queryResult, pool.NewReadBuffer(4096), func(message []byte) error {
+		var err error
+		row, err = parseRawDataRow(message, row)
+		if err != nil {
+			return err
+		}
+		return fn(queryResult.Fields, row)
+	})
+}
@@ -7,15 +7,19 @@ import (
 )
 
 func ProcessSimpleQuery(pgConnection PgConnection, query string) (*models.QueryResult, error) {
+	err := sendSimpleQuery(pgConnection, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return processQueryResult(pgConnection, nil)
+}
+
+func sendSimpleQuery(pgConnection PgConnection, query string) error {
 	buf := pool.NewWriteBuffer(1024)
 	buf.StartMessage(messages.SimpleQuery)
 	buf.WriteString(query)
 	buf.FinishMessage()
 
-	err := pgConnection.sendMessage(buf)
-	if err != nil {
-		return nil, err
-	}
-
-	return processQueryResult(pgConnection, query)
+	return pgConnection.sendMessage(buf)
 }
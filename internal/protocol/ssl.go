@@ -2,18 +2,68 @@ package protocol
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"net"
+	"os"
+	"path/filepath"
 	"postgres-protocol-go/internal/pool"
 	"postgres-protocol-go/internal/protocol/messages"
+	"postgres-protocol-go/pkg/models"
+	"sync"
 )
 
+// https://www.postgresql.org/docs/current/libpq-ssl.html#LIBPQ-SSL-PROTECTION
+const (
+	sslModeDisable    = "disable"
+	sslModeAllow      = "allow"
+	sslModePrefer     = "prefer"
+	sslModeRequire    = "require"
+	sslModeVerifyCA   = "verify-ca"
+	sslModeVerifyFull = "verify-full"
+)
+
+// https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNECT-SSLNEGOTIATION
+const (
+	sslNegotiationPostgres = "postgres"
+	sslNegotiationDirect   = "direct"
+)
+
+// alpnProtocol is the ALPN protocol name of PostgreSQL, which the server
+// must select on direct TLS connections.
+const alpnProtocol = "postgresql"
+
+// sslRootCertSystem is the sslrootcert value for the system roots.
+const sslRootCertSystem = "system"
+
+// sslError is an error negotiating TLS, after which sslmode=prefer retries
+// without TLS.
+type sslError struct {
+	err error
+}
+
+func (e *sslError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sslError) Unwrap() error {
+	return e.err
+}
+
 func ProcessSSL(pgConnection *PgConnection) error {
+	tlsConfig, err := newTLSConfig(pgConnection.connConfig)
+	if err != nil {
+		return err
+	}
+
 	buf := pool.NewWriteBuffer(1024)
 	buf.StartMessage(messages.SSL)
 	buf.WriteInt32(80877103)
 	buf.FinishMessage()
 
-	err := pgConnection.sendMessage(buf)
+	err = pgConnection.sendMessage(buf)
 
 	if err != nil {
 		return err
@@ -26,22 +76,247 @@ func ProcessSSL(pgConnection *PgConnection) error {
 	}
 
 	if string(answer) != "S" {
+		if pgConnection.connConfig.SSLMode == sslModePrefer {
+			if pgConnection.isVerbose() {
+				fmt.Println("SSL refused by the server, continuing without SSL")
+			}
+			return nil
+		}
 		return fmt.Errorf("postgresql server is unwilling to perform SSL")
 	}
 
-	tlsConn := tls.Client(pgConnection.conn, &tls.Config{
-		InsecureSkipVerify: true,
-	})
+	return startTLS(pgConnection, tlsConfig)
+}
+
+// ProcessDirectSSL starts the TLS handshake without an SSLRequest, which
+// PostgreSQL 17 accepts when the client selects the postgresql ALPN
+// protocol.
+func ProcessDirectSSL(pgConnection *PgConnection) error {
+	tlsConfig, err := newTLSConfig(pgConnection.connConfig)
+	if err != nil {
+		return err
+	}
 
+	err = startTLS(pgConnection, tlsConfig)
+	if err != nil {
+		return err
+	}
+
+	tlsConn := pgConnection.conn.(*tls.Conn)
+	if tlsConn.ConnectionState().NegotiatedProtocol != alpnProtocol {
+		return &sslError{errors.New("direct SSL connection was established without ALPN protocol negotiation")}
+	}
+	return nil
+}
+
+func startTLS(pgConnection *PgConnection, tlsConfig *tls.Config) error {
+	tlsConn := tls.Client(pgConnection.conn, tlsConfig)
+
+	pgConnection.setReadDeadline()
+	pgConnection.setWriteDeadline()
 	if err := tlsConn.Handshake(); err != nil {
-		return fmt.Errorf("TLS handshake failed: %v", err)
+		return &sslError{fmt.Errorf("TLS handshake failed: %w", err)}
 	}
 
 	pgConnection.conn = tlsConn
 
 	if pgConnection.isVerbose() {
-		fmt.Println("SSL connection established")
+		if tlsConn.ConnectionState().DidResume {
+			fmt.Println("SSL connection established, session resumed")
+		} else {
+			fmt.Println("SSL connection established")
+		}
+	}
+
+	return nil
+}
+
+// sessionCaches holds a TLS session cache for each client certificate, so
+// that a session is never resumed with another certificate than the one it
+// was authenticated with.
+var sessionCaches sync.Map
+
+func sessionCache(connConfig models.ConnConfig) tls.ClientSessionCache {
+	key := connConfig.SSLCert + "\x00" + connConfig.SSLKey
+	if cache, ok := sessionCaches.Load(key); ok {
+		return cache.(tls.ClientSessionCache)
+	}
+	cache, _ := sessionCaches.LoadOrStore(key, tls.NewLRUClientSessionCache(0))
+	return cache.(tls.ClientSessionCache)
+}
+
+// newTLSConfig returns the TLS configuration for the sslmode and the SSL
+// files of connConfig. The server certificate is verified by
+// VerifyConnection rather than by crypto/tls, so that verify-ca skips the
+// host name check and sslsni=0 doesn't disable it. VerifyConnection also
+// runs on resumed sessions.
+func newTLSConfig(connConfig models.ConnConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{alpnProtocol},
+		ClientSessionCache: sessionCache(connConfig),
+	}
+
+	host := connConfig.Host
+	if connConfig.SSLSNI && net.ParseIP(host) == nil {
+		tlsConfig.ServerName = host
+	}
+
+	cert, err := loadClientCertificate(connConfig)
+	if err != nil {
+		return nil, err
+	}
+	if cert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*cert}
+	}
+
+	mode := connConfig.SSLMode
+	rootCert := connConfig.SSLRootCert
+	if rootCert == sslRootCertSystem && mode != sslModeVerifyFull {
+		return nil, fmt.Errorf("weak sslmode %q may not be used with sslrootcert=system (use verify-full)", mode)
+	}
+	if rootCert == "" {
+		rootCert = defaultSSLFile("root.crt")
+	}
+
+	// Like libpq, require verifies the server certificate when there is a
+	// root certificate file.
+	if mode == sslModeRequire && rootCert != "" {
+		mode = sslModeVerifyCA
+	}
+	if mode != sslModeVerifyCA && mode != sslModeVerifyFull {
+		return tlsConfig, nil
+	}
+
+	if rootCert == "" {
+		return nil, fmt.Errorf("root certificate file does not exist: provide the file with sslrootcert, use sslrootcert=system, or change sslmode to disable server certificate verification")
+	}
+	roots, err := loadRootCertificates(rootCert)
+	if err != nil {
+		return nil, err
+	}
+	crls, err := loadCRLs(connConfig.SSLCRL)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return fmt.Errorf("server did not send a certificate")
+		}
+
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range state.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		if mode == sslModeVerifyFull {
+			opts.DNSName = host
+		}
+
+		chains, err := state.PeerCertificates[0].Verify(opts)
+		if err != nil {
+			return fmt.Errorf("server certificate verification failed: %w", err)
+		}
+		return checkRevocation(chains[0], crls)
+	}
+
+	return tlsConfig, nil
+}
+
+// defaultSSLFile returns the path of name in ~/.postgresql, or an empty
+// string when it does not exist.
+func defaultSSLFile(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(home, ".postgresql", name)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+func loadRootCertificates(path string) (*x509.CertPool, error) {
+	if path == sslRootCertSystem {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the system root certificates: %w", err)
+		}
+		return roots, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read root certificate file: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in root certificate file %s", path)
+	}
+	return roots, nil
+}
+
+// loadCRLs reads the PEM or DER certificate revocation lists of path, none
+// when path is empty.
+func loadCRLs(path string) ([]*x509.RevocationList, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSL CRL file: %w", err)
 	}
 
+	var crls []*x509.RevocationList
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "X509 CRL" {
+			continue
+		}
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSL CRL file: %w", err)
+		}
+		crls = append(crls, crl)
+	}
+
+	if len(crls) == 0 {
+		crl, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSL CRL file: %w", err)
+		}
+		crls = append(crls, crl)
+	}
+	return crls, nil
+}
+
+// checkRevocation returns an error when a certificate of chain is revoked by
+// one of crls. A CRL is only trusted when signed by the issuer in the chain.
+func checkRevocation(chain []*x509.Certificate, crls []*x509.RevocationList) error {
+	for i, cert := range chain[:len(chain)-1] {
+		issuer := chain[i+1]
+		for _, crl := range crls {
+			if string(crl.RawIssuer) != string(cert.RawIssuer) {
+				continue
+			}
+			if err := crl.CheckSignatureFrom(issuer); err != nil {
+				return fmt.Errorf("invalid SSL CRL signature: %w", err)
+			}
+			for _, revoked := range crl.RevokedCertificateEntries {
+				if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+					return errors.New("server certificate has been revoked")
+				}
+			}
+		}
+	}
 	return nil
 }
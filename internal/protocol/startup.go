@@ -12,8 +12,19 @@ func SendStartup(pgConnection PgConnection) {
 	buf.WriteInt32(protocolVersion)
 	buf.WriteString("user")
 	buf.WriteString(pgConnection.connConfig.User)
-	buf.WriteString("database")
-	buf.WriteString(*pgConnection.connConfig.Database)
+	// the server defaults the database to the user name
+	if pgConnection.connConfig.Database != nil {
+		buf.WriteString("database")
+		buf.WriteString(*pgConnection.connConfig.Database)
+	}
+	if pgConnection.connConfig.ApplicationName != "" {
+		buf.WriteString("application_name")
+		buf.WriteString(pgConnection.connConfig.ApplicationName)
+	}
+	if pgConnection.connConfig.Options != "" {
+		buf.WriteString("options")
+		buf.WriteString(pgConnection.connConfig.Options)
+	}
 	buf.WriteString("") // must be null-terminated
 	buf.FinishMessage()
 
@@ -0,0 +1,107 @@
+package protocol
+
+import (
+	"fmt"
+	"postgres-protocol-go/pkg/models"
+)
+
+// https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNECT-TARGET-SESSION-ATTRS
+const (
+	targetSessionAny           = "any"
+	targetSessionReadWrite     = "read-write"
+	targetSessionReadOnly      = "read-only"
+	targetSessionPrimary       = "primary"
+	targetSessionStandby       = "standby"
+	targetSessionPreferStandby = "prefer-standby"
+)
+
+// https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNECT-LOAD-BALANCE-HOSTS
+const (
+	loadBalanceDisable = "disable"
+	loadBalanceRandom  = "random"
+)
+
+// checkTargetSession returns an error when the session of pgConnection does
+// not match attrs. Servers reporting in_hot_standby and
+// default_transaction_read_only at startup (PostgreSQL 14+) are checked
+// without a query.
+func checkTargetSession(pgConnection PgConnection, attrs string) error {
+	switch attrs {
+	case targetSessionReadWrite, targetSessionReadOnly:
+		readOnly, err := isReadOnly(pgConnection)
+		if err != nil {
+			return err
+		}
+		if readOnly && attrs == targetSessionReadWrite {
+			return fmt.Errorf("session is read-only")
+		}
+		if !readOnly && attrs == targetSessionReadOnly {
+			return fmt.Errorf("session is not read-only")
+		}
+
+	case targetSessionPrimary, targetSessionStandby:
+		standby, err := isHotStandby(pgConnection)
+		if err != nil {
+			return err
+		}
+		if standby && attrs == targetSessionPrimary {
+			return fmt.Errorf("server is in hot standby mode")
+		}
+		if !standby && attrs == targetSessionStandby {
+			return fmt.Errorf("server is not in hot standby mode")
+		}
+	}
+	return nil
+}
+
+func isReadOnly(pgConnection PgConnection) (bool, error) {
+	standby, ok1 := pgConnection.serverParams["in_hot_standby"]
+	readOnly, ok2 := pgConnection.serverParams["default_transaction_read_only"]
+	if ok1 && ok2 {
+		return standby == "on" || readOnly == "on", nil
+	}
+	return queryBool(pgConnection, "SHOW transaction_read_only")
+}
+
+func isHotStandby(pgConnection PgConnection) (bool, error) {
+	if standby, ok := pgConnection.serverParams["in_hot_standby"]; ok {
+		return standby == "on", nil
+	}
+	return queryBool(pgConnection, "SELECT pg_catalog.pg_is_in_recovery()")
+}
+
+// queryBool runs query and returns its single value, on or off for a
+// setting and t or f for a boolean.
+func queryBool(pgConnection PgConnection, query string) (bool, error) {
+	result, err := ProcessSimpleQuery(pgConnection, query)
+	if err != nil {
+		return false, err
+	}
+	if len(result.Values) != 1 || len(result.Values[0]) != 1 {
+		return false, fmt.Errorf("unexpected result of %q", query)
+	}
+
+	value, ok := result.Values[0][0].(string)
+	if !ok {
+		return false, fmt.Errorf("unexpected result of %q: %v", query, result.Values[0][0])
+	}
+	return value == "on" || value == "t", nil
+}
+
+// isValidTargetSession reports whether attrs is a target_session_attrs value.
+func isValidTargetSession(attrs string) bool {
+	switch attrs {
+	case targetSessionAny, targetSessionReadWrite, targetSessionReadOnly, targetSessionPrimary, targetSessionStandby, targetSessionPreferStandby:
+		return true
+	}
+	return false
+}
+
+// targetSessionPasses returns the target_session_attrs checked in turn on
+// all hosts: prefer-standby looks for a standby first, then for any server.
+func targetSessionPasses(connConfig models.ConnConfig) []string {
+	if connConfig.TargetSessionAttrs == targetSessionPreferStandby {
+		return []string{targetSessionStandby, targetSessionAny}
+	}
+	return []string{connConfig.TargetSessionAttrs}
+}
@@ -0,0 +1,124 @@
+package protocol
+
+import (
+	"fmt"
+	"net"
+	"postgres-protocol-go/pkg/models"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// connDeadline is the deadline of the operation in progress on a
+// connection, set while connecting. The read and write timeouts are applied
+// on top of it before each read and write.
+type connDeadline struct {
+	mu sync.Mutex
+	t  time.Time
+	// interrupted is set once the connection was interrupted, see interrupt.
+	interrupted bool
+}
+
+// setDeadline sets the deadline of the operation in progress, zero for none.
+func (pg *PgConnection) setDeadline(t time.Time) {
+	pg.deadline.mu.Lock()
+	defer pg.deadline.mu.Unlock()
+
+	pg.deadline.t = t
+	pg.conn.SetDeadline(t)
+}
+
+// interrupt makes the reads and writes in progress fail. The connection is
+// left in the middle of a message exchange and can't be used anymore.
+func (pg *PgConnection) interrupt() {
+	pg.deadline.mu.Lock()
+	defer pg.deadline.mu.Unlock()
+
+	pg.deadline.interrupted = true
+	pg.deadline.t = time.Unix(1, 0)
+	pg.conn.SetDeadline(pg.deadline.t)
+}
+
+// isInterrupted reports whether interrupt was called.
+func (pg *PgConnection) isInterrupted() bool {
+	pg.deadline.mu.Lock()
+	defer pg.deadline.mu.Unlock()
+
+	return pg.deadline.interrupted
+}
+
+// setReadDeadline applies the read timeout to the next read.
+func (pg *PgConnection) setReadDeadline() {
+	if timeout := pg.connConfig.ReadTimeout; timeout > 0 {
+		pg.deadline.mu.Lock()
+		defer pg.deadline.mu.Unlock()
+
+		pg.conn.SetReadDeadline(earliest(pg.deadline.t, time.Now().Add(timeout)))
+	}
+}
+
+// setWriteDeadline applies the write timeout to the next write.
+func (pg *PgConnection) setWriteDeadline() {
+	if timeout := pg.connConfig.WriteTimeout; timeout > 0 {
+		pg.deadline.mu.Lock()
+		defer pg.deadline.mu.Unlock()
+
+		pg.conn.SetWriteDeadline(earliest(pg.deadline.t, time.Now().Add(timeout)))
+	}
+}
+
+// earliest returns the earliest of deadline, zero for none, and t.
+func earliest(deadline, t time.Time) time.Time {
+	if !deadline.IsZero() && deadline.Before(t) {
+		return deadline
+	}
+	return t
+}
+
+// setTCPOptions applies the keepalive settings and the user timeout of
+// connConfig to conn. The user timeout applies with keepalives disabled too.
+func setTCPOptions(conn *net.TCPConn, connConfig models.ConnConfig) error {
+	if err := conn.SetKeepAlive(connConfig.Keepalives); err != nil {
+		return err
+	}
+	if connConfig.Keepalives && connConfig.KeepalivesIdle > 0 {
+		if err := conn.SetKeepAlivePeriod(connConfig.KeepalivesIdle); err != nil {
+			return err
+		}
+	}
+
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	err = rawConn.Control(func(fd uintptr) {
+		sockErr = setSocketOptions(fd, connConfig)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// parseTimeout parses the value of key as a number of seconds or a duration
+// like 500ms.
+func parseTimeout(key, value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid %s value: %q", key, value)
+	}
+	return timeout, nil
+}
+
+// parseSeconds parses the value of key as a number of seconds.
+func parseSeconds(key, value string) (time.Duration, error) {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid %s value: %q", key, value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
@@ -0,0 +1,33 @@
+package protocol
+
+import (
+	"postgres-protocol-go/pkg/models"
+	"syscall"
+)
+
+// tcpUserTimeout is TCP_USER_TIMEOUT, missing from the syscall package.
+const tcpUserTimeout = 0x12
+
+// setSocketOptions sets the keepalive interval and count, when keepalives
+// are enabled, and the user timeout of the socket fd.
+func setSocketOptions(fd uintptr, connConfig models.ConnConfig) error {
+	if connConfig.Keepalives && connConfig.KeepalivesInterval > 0 {
+		err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL, int(connConfig.KeepalivesInterval.Seconds()))
+		if err != nil {
+			return err
+		}
+	}
+	if connConfig.Keepalives && connConfig.KeepalivesCount > 0 {
+		err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPCNT, connConfig.KeepalivesCount)
+		if err != nil {
+			return err
+		}
+	}
+	if connConfig.TCPUserTimeout > 0 {
+		err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, tcpUserTimeout, int(connConfig.TCPUserTimeout.Milliseconds()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package protocol
+
+import (
+	"net"
+	"postgres-protocol-go/pkg/models"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// getsockopt returns the value of the socket option opt at level of conn.
+func getsockopt(t *testing.T, conn *net.TCPConn, level, opt int) int {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value int
+	var sockErr error
+	err = rawConn.Control(func(fd uintptr) {
+		value, sockErr = syscall.GetsockoptInt(int(fd), level, opt)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sockErr != nil {
+		t.Fatal(sockErr)
+	}
+	return value
+}
+
+func TestSetTCPOptions(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	tests := []struct {
+		name        string
+		connConfig  models.ConnConfig
+		keepalive   int
+		userTimeout int
+	}{
+		{"user timeout without keepalives", models.ConnConfig{TCPUserTimeout: 10 * time.Second}, 0, 10000},
+		{"keepalives and user timeout", models.ConnConfig{Keepalives: true, KeepalivesIdle: 30 * time.Second, KeepalivesInterval: 5 * time.Second, KeepalivesCount: 3, TCPUserTimeout: 10 * time.Second}, 1, 10000},
+		{"no keepalives", models.ConnConfig{}, 0, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			tcpConn := conn.(*net.TCPConn)
+
+			if err := setTCPOptions(tcpConn, tc.connConfig); err != nil {
+				t.Fatal(err)
+			}
+			if keepalive := getsockopt(t, tcpConn, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE); keepalive != tc.keepalive {
+				t.Errorf("expected SO_KEEPALIVE %d, got %d", tc.keepalive, keepalive)
+			}
+			if userTimeout := getsockopt(t, tcpConn, syscall.IPPROTO_TCP, tcpUserTimeout); userTimeout != tc.userTimeout {
+				t.Errorf("expected TCP_USER_TIMEOUT %d, got %d", tc.userTimeout, userTimeout)
+			}
+		})
+	}
+}
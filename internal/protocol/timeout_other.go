@@ -0,0 +1,11 @@
+//go:build !linux
+
+package protocol
+
+import "postgres-protocol-go/pkg/models"
+
+// setSocketOptions ignores the keepalive interval and count and the user
+// timeout, like libpq on systems without them.
+func setSocketOptions(fd uintptr, connConfig models.ConnConfig) error {
+	return nil
+}
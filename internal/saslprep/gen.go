@@ -0,0 +1,202 @@
+//go:build ignore
+
+// gen.go generates norm_tables.go from the Unicode Character Database:
+//
+//	go run gen.go -ucd path/to/ucd -version 17.0.0 -output norm_tables.go
+//
+// The ucd directory must hold UnicodeData.txt and
+// DerivedNormalizationProps.txt of that version.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ucdDir  = flag.String("ucd", ".", "directory of the Unicode Character Database files")
+	version = flag.String("version", "", "Unicode version of the files")
+	output  = flag.String("output", "norm_tables.go", "output file")
+)
+
+const (
+	hangulSBase = 0xAC00
+	hangulSEnd  = 0xD7A3
+)
+
+type mapping struct {
+	compat bool
+	runes  []rune
+}
+
+func main() {
+	flag.Parse()
+	if *version == "" {
+		log.Fatal("-version is required")
+	}
+
+	classes := map[rune]uint8{}
+	mappings := map[rune]mapping{}
+	readLines("UnicodeData.txt", func(fields []string) {
+		r := parseRune(fields[0])
+		class, err := strconv.Atoi(fields[3])
+		if err != nil {
+			log.Fatalf("invalid combining class %q", fields[3])
+		}
+		if class != 0 {
+			classes[r] = uint8(class)
+		}
+		if fields[5] == "" {
+			return
+		}
+		m := mapping{}
+		for _, f := range strings.Fields(fields[5]) {
+			if strings.HasPrefix(f, "<") {
+				m.compat = true
+				continue
+			}
+			m.runes = append(m.runes, parseRune(f))
+		}
+		mappings[r] = m
+	})
+
+	excluded := map[rune]bool{}
+	readLines("DerivedNormalizationProps.txt", func(fields []string) {
+		if len(fields) < 2 || fields[1] != "Full_Composition_Exclusion" {
+			return
+		}
+		lo, hi, _ := strings.Cut(fields[0], "..")
+		first, last := parseRune(lo), parseRune(lo)
+		if hi != "" {
+			last = parseRune(hi)
+		}
+		for r := first; r <= last; r++ {
+			excluded[r] = true
+		}
+	})
+
+	var decomposed []rune
+	var composites []rune
+	for r, m := range mappings {
+		if r >= hangulSBase && r <= hangulSEnd {
+			continue
+		}
+		decomposed = append(decomposed, r)
+		if !m.compat && len(m.runes) == 2 && !excluded[r] {
+			composites = append(composites, r)
+		}
+	}
+	sortRunes(decomposed)
+	sortRunes(composites)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen.go; DO NOT EDIT.\n\npackage saslprep\n\n")
+	fmt.Fprintf(&b, "// unicodeVersion is the Unicode version of the normalization tables.\nconst unicodeVersion = %q\n\n", *version)
+
+	b.WriteString("// decompositions maps code points to their full compatibility\n// decomposition in canonical order. Hangul syllables are decomposed\n// algorithmically.\nvar decompositions = map[rune]string{\n")
+	for _, r := range decomposed {
+		d := decompose(r, mappings)
+		reorder(d, classes)
+		fmt.Fprintf(&b, "0x%04X: %s,\n", r, strconv.QuoteToASCII(string(d)))
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// combiningClasses holds the non-zero canonical combining classes.\nvar combiningClasses = map[rune]uint8{\n")
+	var classRunes []rune
+	for r := range classes {
+		classRunes = append(classRunes, r)
+	}
+	sortRunes(classRunes)
+	for _, r := range classRunes {
+		fmt.Fprintf(&b, "0x%04X: %d,\n", r, classes[r])
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// compositions maps the canonical decomposition pairs of the primary\n// composites to them. Hangul syllables are composed algorithmically.\nvar compositions = map[[2]rune]rune{\n")
+	for _, r := range composites {
+		pair := mappings[r].runes
+		fmt.Fprintf(&b, "{0x%04X, 0x%04X}: 0x%04X,\n", pair[0], pair[1], r)
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// decompose returns the full compatibility decomposition of r.
+func decompose(r rune, mappings map[rune]mapping) []rune {
+	m, ok := mappings[r]
+	if !ok {
+		return []rune{r}
+	}
+	var out []rune
+	for _, c := range m.runes {
+		out = append(out, decompose(c, mappings)...)
+	}
+	return out
+}
+
+// reorder sorts the runs of non-starters of runes by combining class.
+func reorder(runes []rune, classes map[rune]uint8) {
+	for i := 1; i < len(runes); i++ {
+		for j := i; j > 0; j-- {
+			class := classes[runes[j]]
+			if class == 0 || classes[runes[j-1]] <= class {
+				break
+			}
+			runes[j], runes[j-1] = runes[j-1], runes[j]
+		}
+	}
+}
+
+// readLines calls fn with the semicolon-separated fields of each line of the
+// UCD file name, without comments.
+func readLines(name string, fn func(fields []string)) {
+	f, err := os.Open(filepath.Join(*ucdDir, name))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, ";")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		fn(fields)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parseRune(s string) rune {
+	n, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		log.Fatalf("invalid code point %q", s)
+	}
+	return rune(n)
+}
+
+func sortRunes(runes []rune) {
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+}
@@ -0,0 +1,98 @@
+package saslprep
+
+// Hangul syllables are decomposed and composed algorithmically, see
+// section 3.12 of the Unicode Standard.
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+// nfkc returns runes in Unicode normalization form KC.
+func nfkc(runes []rune) []rune {
+	return compose(decompose(runes))
+}
+
+// decompose returns the full compatibility decomposition of runes in
+// canonical order.
+func decompose(runes []rune) []rune {
+	out := make([]rune, 0, len(runes))
+	for _, r := range runes {
+		if s := r - hangulSBase; s >= 0 && s < hangulSCount {
+			out = append(out, hangulLBase+s/hangulNCount, hangulVBase+(s%hangulNCount)/hangulTCount)
+			if t := s % hangulTCount; t != 0 {
+				out = append(out, hangulTBase+t)
+			}
+			continue
+		}
+		if d, ok := decompositions[r]; ok {
+			out = append(out, []rune(d)...)
+			continue
+		}
+		out = append(out, r)
+	}
+
+	// Sort each run of non-starters by combining class, keeping the order
+	// of equal classes.
+	for i := 1; i < len(out); i++ {
+		for j := i; j > 0; j-- {
+			class := combiningClasses[out[j]]
+			if class == 0 || combiningClasses[out[j-1]] <= class {
+				break
+			}
+			out[j], out[j-1] = out[j-1], out[j]
+		}
+	}
+	return out
+}
+
+// compose canonically composes decomposed runes in place.
+func compose(runes []rune) []rune {
+	if len(runes) == 0 {
+		return runes
+	}
+
+	starter := 0
+	lastClass := int(combiningClasses[runes[0]])
+	if lastClass != 0 {
+		// nothing composes with a leading non-starter
+		lastClass = 256
+	}
+
+	n := 1
+	for _, r := range runes[1:] {
+		class := int(combiningClasses[r])
+		if composite, ok := composePair(runes[starter], r); ok && (lastClass < class || lastClass == 0) {
+			runes[starter] = composite
+			continue
+		}
+		if class == 0 {
+			starter = n
+		}
+		lastClass = class
+		runes[n] = r
+		n++
+	}
+	return runes[:n]
+}
+
+func composePair(a, b rune) (rune, bool) {
+	if l := a - hangulLBase; l >= 0 && l < hangulLCount {
+		if v := b - hangulVBase; v >= 0 && v < hangulVCount {
+			return hangulSBase + (l*hangulVCount+v)*hangulTCount, true
+		}
+	}
+	if s := a - hangulSBase; s >= 0 && s < hangulSCount && s%hangulTCount == 0 {
+		if t := b - hangulTBase; t > 0 && t < hangulTCount {
+			return a + t, true
+		}
+	}
+	composite, ok := compositions[[2]rune{a, b}]
+	return composite, ok
+}
@@ -0,0 +1,88 @@
+// Package saslprep implements the SASLprep profile of stringprep (RFC 4013)
+// like PostgreSQL, which prepares SCRAM passwords with it.
+package saslprep
+
+import (
+	"errors"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidUTF8 = errors.New("saslprep: invalid UTF-8")
+	ErrProhibited  = errors.New("saslprep: prohibited character")
+)
+
+// Prepare returns s prepared with SASLprep as a stored string: unassigned
+// code points are prohibited and an empty result is an error. Like
+// PostgreSQL, ASCII strings are returned as is.
+func Prepare(s string) (string, error) {
+	if isASCII(s) {
+		return s, nil
+	}
+	if !utf8.ValidString(s) {
+		return "", ErrInvalidUTF8
+	}
+
+	// 1. Map
+	runes := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case unicode.Is(nonASCIISpace, r):
+			runes = append(runes, ' ')
+		case unicode.Is(mappedToNothing, r):
+		default:
+			runes = append(runes, r)
+		}
+	}
+	if len(runes) == 0 {
+		return "", ErrProhibited
+	}
+
+	// 2. Normalize
+	runes = nfkc(runes)
+
+	// 3. Prohibit
+	hasRandAL, hasL := false, false
+	for _, r := range runes {
+		if unicode.Is(prohibited, r) || unicode.Is(unassigned, r) {
+			return "", ErrProhibited
+		}
+		if unicode.Is(randALCat, r) {
+			hasRandAL = true
+		} else if unicode.Is(lCat, r) {
+			hasL = true
+		}
+	}
+
+	// 4. Check bidi: a string with right-to-left characters must not have
+	// left-to-right characters, and must start and end with right-to-left
+	// characters.
+	if hasRandAL {
+		if hasL || !unicode.Is(randALCat, runes[0]) || !unicode.Is(randALCat, runes[len(runes)-1]) {
+			return "", ErrProhibited
+		}
+	}
+
+	return string(runes), nil
+}
+
+// Password returns password prepared with SASLprep, or password unchanged
+// when it can't be prepared, which is what the server does when it hashes
+// the password.
+func Password(password string) string {
+	prepared, err := Prepare(password)
+	if err != nil {
+		return password
+	}
+	return prepared
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package models
+
+// ColumnarResult is a query result stored by column, see Column.
+type ColumnarResult struct {
+	Command    string
+	CommandTag CommandTag
+	Fields     []Field
+	RowCount   int
+	Columns    []Column
+}
+
+// RowsAffected returns the number of rows reported in the command tag.
+func (r *ColumnarResult) RowsAffected() int64 {
+	return r.CommandTag.RowsAffected()
+}
+
+// Column returns the first column named name, or nil when there is none.
+func (r *ColumnarResult) Column(name string) *Column {
+	for i := range r.Columns {
+		if r.Columns[i].Field.Name == name {
+			return &r.Columns[i]
+		}
+	}
+	return nil
+}
+
+// Column holds the values of one result column in the slice matching its
+// type: Int64s for int2, int4 and int8, Float64s for float4, float8 and
+// numeric, which may lose precision, Bools for bool and Strings, in text
+// format, for any other type. NULL values are zero in the slice and set in
+// Nulls.
+type Column struct {
+	Field    Field
+	Int64s   []int64
+	Float64s []float64
+	Bools    []bool
+	Strings  []string
+	// Nulls is a bitmap of the NULL values, bit i%8 of Nulls[i/8] is set
+	// when the value of row i is NULL.
+	Nulls []byte
+}
+
+// IsNull reports whether the value of row i is NULL.
+func (c *Column) IsNull(i int) bool {
+	return i/8 < len(c.Nulls) && c.Nulls[i/8]&(1<<(i%8)) != 0
+}
+
+// SetNull marks the value of row i as NULL.
+func (c *Column) SetNull(i int) {
+	for len(c.Nulls) <= i/8 {
+		c.Nulls = append(c.Nulls, 0)
+	}
+	c.Nulls[i/8] |= 1 << (i % 8)
+}
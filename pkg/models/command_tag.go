@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CommandTag is the tag of a CommandComplete message, like INSERT 0 5,
+// UPDATE 3 or CREATE TABLE.
+type CommandTag string
+
+// Command returns the command name of the tag, without its row counts.
+func (t CommandTag) Command() string {
+	fields := strings.Fields(string(t))
+	for len(fields) > 1 && isCount(fields[len(fields)-1]) {
+		fields = fields[:len(fields)-1]
+	}
+	return strings.Join(fields, " ")
+}
+
+// RowsAffected returns the number of rows inserted, updated, deleted,
+// merged, selected, moved, fetched or copied, or 0 for other commands.
+func (t CommandTag) RowsAffected() int64 {
+	fields := strings.Fields(string(t))
+	if len(fields) < 2 {
+		return 0
+	}
+	switch fields[0] {
+	case "INSERT", "UPDATE", "DELETE", "MERGE", "SELECT", "MOVE", "FETCH", "COPY":
+		n, err := strconv.ParseInt(fields[len(fields)-1], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return n
+	}
+	return 0
+}
+
+func isCount(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
@@ -1,14 +1,82 @@
 package models
 
+import "time"
+
 type ConnConfig struct {
-	Port     int
-	Host     string
+	// Port and Host are the server connected to, the first of Ports and
+	// Hosts until a connection is made.
+	Port int
+	Host string
+	// Hosts and Ports list the servers tried in turn, from comma-separated
+	// host and port parameters.
+	Hosts    []string
+	Ports    []int
 	User     string
-	Secure   bool
 	Database *string
 	Password *string
+	// PassFile is the password file used when there is no password,
+	// ~/.pgpass by default.
+	PassFile string
+	// ApplicationName is reported by the server in pg_stat_activity.
+	ApplicationName string
+	// Options are command-line options sent to the server at startup, like
+	// -c search_path=app.
+	Options string
+	// ConnectTimeout limits the time to connect to each host, from dialing
+	// to the end of authentication, zero waits indefinitely.
+	ConnectTimeout time.Duration
+	// ReadTimeout and WriteTimeout limit each read and write on the
+	// connection, zero waits indefinitely.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// Keepalives enables TCP keepalives, with the system defaults for the
+	// zero values of KeepalivesIdle, KeepalivesInterval and KeepalivesCount.
+	Keepalives         bool
+	KeepalivesIdle     time.Duration
+	KeepalivesInterval time.Duration
+	KeepalivesCount    int
+	// TCPUserTimeout is the time transmitted data may remain
+	// unacknowledged before the connection is closed, on Linux.
+	TCPUserTimeout time.Duration
+	// LoadBalanceHosts is disable to try Hosts in order, or random to try
+	// them in random order.
+	LoadBalanceHosts string
+	// TargetSessionAttrs is the kind of server accepted among Hosts: any,
+	// read-write, read-only, primary, standby or prefer-standby.
+	TargetSessionAttrs string
+
+	// SSLMode is one of disable, allow, prefer, require, verify-ca and
+	// verify-full, as in libpq. prefer is the default.
+	SSLMode string
+	// SSLRootCert is the file of the certificate authorities used to verify
+	// the server, or "system" for the system roots.
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+	// SSLPassword decrypts SSLKey when it is encrypted.
+	SSLPassword string
+	// SSLSNI sends the host name in the TLS handshake.
+	SSLSNI bool
+	// SSLCRL is the file of the certificate revocation lists checked when
+	// the server certificate is verified.
+	SSLCRL string
+	// SSLNegotiation is postgres to request TLS with an SSLRequest, or
+	// direct to start the TLS handshake right away.
+	SSLNegotiation string
+	// ChannelBinding is disable, prefer or require, to use
+	// SCRAM-SHA-256-PLUS with tls-server-end-point channel binding.
+	ChannelBinding string
 }
 
 type DriveConfig struct {
 	Verbose bool
+	// LoadTypes discovers the user-defined types of the database after
+	// connecting, see PgConnection.LoadTypes.
+	LoadTypes bool
+	// PinParamTypes declares query parameters in Parse with the type of their
+	// Go value, see types.BinaryOID, so SELECT $1 with an int64 resolves to
+	// int8. It is off by default because a pinned type must then match the
+	// query: an int compared to a text column or an []int64 for an int4[]
+	// fails instead of being converted. types.Typed pins a single parameter.
+	PinParamTypes bool
 }
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PgError is an ErrorResponse sent by the server.
+// https://www.postgresql.org/docs/current/protocol-error-fields.html
+type PgError struct {
+	Severity string
+	Code     string // SQLSTATE
+	Message  string
+	Detail   string
+	Hint     string
+	// Position is the 1-based character index of the error in the query,
+	// 0 when the error has no position.
+	Position int
+	// InternalPosition is like Position for InternalQuery, a query generated
+	// by the server such as the body of a SQL function.
+	InternalPosition int
+	InternalQuery    string
+	Where            string
+	SchemaName       string
+	TableName        string
+	ColumnName       string
+	DataTypeName     string
+	ConstraintName   string
+	File             string
+	Line             int
+	Routine          string
+}
+
+func (e *PgError) Error() string {
+	if e.Position > 0 {
+		return fmt.Sprintf("%s: %s at position %d (SQLSTATE %s)", e.Severity, e.Message, e.Position, e.Code)
+	}
+	return fmt.Sprintf("%s: %s (SQLSTATE %s)", e.Severity, e.Message, e.Code)
+}
+
+// ErrNoRows is returned by Row.Scan when the query returned no rows.
+var ErrNoRows = errors.New("no rows in result set")
+
+// ErrConnBroken is returned by a connection left in the middle of a message
+// exchange, by a canceled context when the query couldn't be canceled on the
+// server or by an invalid message. The connection must be closed.
+var ErrConnBroken = errors.New("connection is broken by an interrupted query")
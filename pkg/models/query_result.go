@@ -1,19 +1,89 @@
 package models
 
+import "postgres-protocol-go/pkg/types"
+
 type QueryResult struct {
-	Command  string
-	Fields   []Field
+	// Command is the command name of CommandTag, like INSERT or CREATE TABLE.
+	Command    string
+	CommandTag CommandTag
+	Fields     []Field
+	// RowCount is the number of rows affected, or returned for commands
+	// that don't report a number of rows.
 	RowCount int
-	Rows     []map[string]interface{}
+	// Rows maps column names to values, duplicate names keep the last column.
+	Rows []map[string]interface{}
+	// Values are the rows in column order, Values[i][j] is the value of
+	// Fields[j] in row i.
+	Values [][]any
+}
+
+// RowsAffected returns the number of rows reported in the command tag.
+func (r *QueryResult) RowsAffected() int64 {
+	return r.CommandTag.RowsAffected()
+}
+
+// FieldIndex returns the index in Fields of the first column named name, or
+// -1 when there is none.
+func (r *QueryResult) FieldIndex(name string) int {
+	for i, field := range r.Fields {
+		if field.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Value returns the value of the first column named name in row i, ok is
+// false when there is no such column.
+func (r *QueryResult) Value(i int, name string) (value any, ok bool) {
+	idx := r.FieldIndex(name)
+	if idx == -1 {
+		return nil, false
+	}
+	return r.Values[i][idx], true
 }
 
-// todo: parse these int for compreensible values
 type Field struct {
-	Name         string
-	TableOID     uint32
-	AttrNum      uint16
-	DataTypeOID  uint32
-	Size         uint16
+	Name        string
+	TableOID    uint32 // 0 when the column is not a table column
+	AttrNum     uint16
+	DataTypeOID uint32
+	// DataTypeName is the type with its modifiers, like varchar(255), empty
+	// when the type is unknown, see types.Registry.TypeName.
+	DataTypeName string
+	Size         uint16 // pg_type.typlen, see TypeLen
 	TypeModifier uint32
 	Format       string // text | binary
 }
+
+// ColumnInfo is the table column a result field comes from.
+type ColumnInfo struct {
+	Schema  string
+	Table   string
+	Column  string
+	NotNull bool
+}
+
+// TypeLen returns the size of the type in bytes, or -1 for variable-length
+// types and -2 for null-terminated strings.
+func (f Field) TypeLen() int {
+	return int(int16(f.Size))
+}
+
+// Length returns the declared length of a varchar, bpchar, bit or varbit
+// column, ok is false for other columns and when there is no length.
+func (f Field) Length() (length int, ok bool) {
+	return types.TypmodLength(f.DataTypeOID, int32(f.TypeModifier))
+}
+
+// PrecisionScale returns the declared precision and scale of a numeric column,
+// ok is false when the column is not numeric or has no declared precision.
+func (f Field) PrecisionScale() (precision, scale int, ok bool) {
+	return types.TypmodPrecisionScale(f.DataTypeOID, int32(f.TypeModifier))
+}
+
+// Precision returns the fractional seconds precision of a time, timestamp or
+// interval column, ok is false for other columns and when there is none.
+func (f Field) Precision() (precision int, ok bool) {
+	return types.TypmodPrecision(f.DataTypeOID, int32(f.TypeModifier))
+}
@@ -5,6 +5,7 @@ import (
 	"math"
 	"reflect"
 	"strconv"
+	"time"
 	"unicode/utf8"
 )
 
@@ -26,8 +27,8 @@ func Append(b []byte, v interface{}, flags int) []byte {
 		return appendFloat(b, v, flags, 64)
 	case string:
 		return AppendString(b, v, flags)
-	// case time.Time:
-	// 	return AppendTime(b, v, flags)
+	case time.Time:
+		return AppendTime(b, v, flags)
 	case []byte:
 		return AppendBytes(b, v, flags)
 	case ValueAppender:
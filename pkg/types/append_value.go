@@ -7,11 +7,13 @@ import (
 	"reflect"
 	"strconv"
 	"sync"
+	"time"
 )
 
 var (
 	driverValuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
 	appenderType     = reflect.TypeOf((*ValueAppender)(nil)).Elem()
+	timeType         = reflect.TypeOf((*time.Time)(nil)).Elem()
 )
 
 type AppenderFunc func([]byte, reflect.Value, int) []byte
@@ -69,11 +71,44 @@ func Appender(typ reflect.Type) AppenderFunc {
 		return v.(AppenderFunc)
 	}
 	fn := appender(typ)
+	if fn == nil {
+		fn = unsupportedAppender(typ)
+	}
 	_, _ = appendersMap.LoadOrStore(typ, fn)
 	return fn
 }
 
+// hasAppender reports whether values of typ have a text encoding.
+func hasAppender(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr && !typ.Implements(appenderType) && !typ.Implements(driverValuerType) {
+		return hasAppender(typ.Elem())
+	}
+	return appender(typ) != nil
+}
+
+// unsupportedAppender appends an error for values without a text encoding,
+// like slices other than []byte and maps other than hstore maps.
+func unsupportedAppender(typ reflect.Type) AppenderFunc {
+	return func(b []byte, v reflect.Value, _ int) []byte {
+		return AppendError(b, fmt.Errorf("pg: Append(unsupported %s)", typ))
+	}
+}
+
 func appender(typ reflect.Type) AppenderFunc {
+	switch typ {
+	case timeType:
+		return appendTimeValue
+	case ipType:
+		return appendIPValue
+	case ipNetType:
+		return appendIPNetValue
+	case netipAddrType:
+		return appendNetipAddrValue
+	case netipPrefixType:
+		return appendNetipPrefixValue
+	case hardwareAddrType:
+		return appendHardwareAddrValue
+	}
 	if typ.Implements(appenderType) {
 		return appendAppenderValue
 	}
@@ -89,6 +124,12 @@ func appender(typ reflect.Type) AppenderFunc {
 		if typ.Elem().Kind() == reflect.Uint8 {
 			return appendBytesValue
 		}
+	case reflect.Struct:
+		return appendStructValue
+	case reflect.Map:
+		if typ == hstoreMapType {
+			return appendHstoreValue
+		}
 	}
 	return appenders[kind]
 }
@@ -159,10 +200,10 @@ func appendStringValue(b []byte, v reflect.Value, flags int) []byte {
 	return AppendString(b, v.String(), flags)
 }
 
-// func appendTimeValue(b []byte, v reflect.Value, flags int) []byte {
-// 	tm := v.Interface().(time.Time)
-// 	return AppendTime(b, tm, flags)
-// }
+func appendTimeValue(b []byte, v reflect.Value, flags int) []byte {
+	tm := v.Interface().(time.Time)
+	return AppendTime(b, tm, flags)
+}
 
 func appendIPValue(b []byte, v reflect.Value, flags int) []byte {
 	ip := v.Interface().(net.IP)
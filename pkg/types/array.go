@@ -0,0 +1,252 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ElemDecoder decodes one array element, src is never nil.
+type ElemDecoder func(src []byte, format int16) (interface{}, error)
+
+// ParseArray decodes an array value into []interface{}, nesting slices for
+// multi-dimensional arrays. NULL elements are nil and the others are decoded
+// with decode, or returned as string/[]byte when decode is nil.
+func ParseArray(src []byte, format int16, decode ElemDecoder) ([]interface{}, error) {
+	if decode == nil {
+		decode = rawElem
+	}
+	if format == BinaryFormat {
+		return decodeArrayBinary(src, decode)
+	}
+	return parseArrayText(string(src), decode)
+}
+
+func rawElem(src []byte, format int16) (interface{}, error) {
+	if format == BinaryFormat {
+		return append([]byte(nil), src...), nil
+	}
+	return string(src), nil
+}
+
+// decodeArrayBinary decodes the binary array format: the number of
+// dimensions, a has-nulls flag and the element OID, then the length and lower
+// bound of each dimension, then the length-prefixed elements.
+func decodeArrayBinary(src []byte, decode ElemDecoder) ([]interface{}, error) {
+	if len(src) < 12 {
+		return nil, fmt.Errorf("pg: invalid binary array length %d", len(src))
+	}
+	ndim := int(int32(binary.BigEndian.Uint32(src)))
+	if ndim == 0 {
+		return []interface{}{}, nil
+	}
+	if ndim < 0 || len(src) < 12+8*ndim {
+		return nil, fmt.Errorf("pg: invalid binary array dimensions %d", ndim)
+	}
+
+	dims := make([]int, ndim)
+	for i := range dims {
+		dims[i] = int(int32(binary.BigEndian.Uint32(src[12+8*i:])))
+		if dims[i] < 0 {
+			return nil, fmt.Errorf("pg: invalid binary array dimension %d", dims[i])
+		}
+	}
+
+	rest := src[12+8*ndim:]
+	values, rest, err := decodeArrayBinaryDim(rest, dims, decode)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("pg: %d trailing bytes in binary array", len(rest))
+	}
+	return values, nil
+}
+
+func decodeArrayBinaryDim(src []byte, dims []int, decode ElemDecoder) ([]interface{}, []byte, error) {
+	values := make([]interface{}, dims[0])
+	for i := range values {
+		if len(dims) > 1 {
+			sub, rest, err := decodeArrayBinaryDim(src, dims[1:], decode)
+			if err != nil {
+				return nil, nil, err
+			}
+			values[i], src = sub, rest
+			continue
+		}
+
+		if len(src) < 4 {
+			return nil, nil, fmt.Errorf("pg: truncated binary array")
+		}
+		n := int(int32(binary.BigEndian.Uint32(src)))
+		src = src[4:]
+		if n < 0 {
+			continue
+		}
+		if len(src) < n {
+			return nil, nil, fmt.Errorf("pg: truncated binary array")
+		}
+		v, err := decode(src[:n:n], BinaryFormat)
+		if err != nil {
+			return nil, nil, err
+		}
+		values[i], src = v, src[n:]
+	}
+	return values, src, nil
+}
+
+// parseArrayText decodes the text array format, {a,"b c",NULL,{d}}, with an
+// optional [lower:upper]= dimension decoration.
+func parseArrayText(s string, decode ElemDecoder) ([]interface{}, error) {
+	if strings.HasPrefix(s, "[") {
+		idx := strings.Index(s, "=")
+		if idx == -1 {
+			return nil, fmt.Errorf("pg: invalid array %q", s)
+		}
+		s = s[idx+1:]
+	}
+
+	p := arrayParser{s: s, decode: decode}
+	values, err := p.parseLevel()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.s) {
+		return nil, fmt.Errorf("pg: invalid array %q", s)
+	}
+	return values, nil
+}
+
+type arrayParser struct {
+	s      string
+	pos    int
+	decode ElemDecoder
+}
+
+func (p *arrayParser) parseLevel() ([]interface{}, error) {
+	if p.pos >= len(p.s) || p.s[p.pos] != '{' {
+		return nil, fmt.Errorf("pg: invalid array %q", p.s)
+	}
+	p.pos++
+
+	values := []interface{}{}
+	if p.pos < len(p.s) && p.s[p.pos] == '}' {
+		p.pos++
+		return values, nil
+	}
+
+	for {
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("pg: invalid array %q", p.s)
+		}
+
+		if p.s[p.pos] == '{' {
+			sub, err := p.parseLevel()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, sub)
+		} else {
+			elem, quoted, err := p.readElem()
+			if err != nil {
+				return nil, err
+			}
+			if !quoted && strings.EqualFold(elem, "NULL") {
+				values = append(values, nil)
+			} else {
+				v, err := p.decode([]byte(elem), TextFormat)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, v)
+			}
+		}
+
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("pg: invalid array %q", p.s)
+		}
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return values, nil
+		default:
+			return nil, fmt.Errorf("pg: invalid array %q", p.s)
+		}
+	}
+}
+
+func (p *arrayParser) readElem() (string, bool, error) {
+	var sb strings.Builder
+
+	if p.s[p.pos] == '"' {
+		p.pos++
+		for p.pos < len(p.s) {
+			c := p.s[p.pos]
+			p.pos++
+			switch c {
+			case '"':
+				return sb.String(), true, nil
+			case '\\':
+				if p.pos >= len(p.s) {
+					return "", false, fmt.Errorf("pg: invalid array %q", p.s)
+				}
+				sb.WriteByte(p.s[p.pos])
+				p.pos++
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		return "", false, fmt.Errorf("pg: invalid array %q", p.s)
+	}
+
+	for p.pos < len(p.s) && p.s[p.pos] != ',' && p.s[p.pos] != '}' {
+		if p.s[p.pos] == '\\' && p.pos+1 < len(p.s) {
+			p.pos++
+		}
+		sb.WriteByte(p.s[p.pos])
+		p.pos++
+	}
+	return strings.TrimSpace(sb.String()), false, nil
+}
+
+// appendArrayBinary appends a one-dimensional slice in the binary array
+// format, see decodeArrayBinary.
+func appendArrayBinary(b []byte, slice interface{}) ([]byte, error) {
+	v := reflect.ValueOf(slice)
+	elemOID := BinaryOID(reflect.Zero(v.Type().Elem()).Interface())
+
+	ndim := 1
+	if v.Len() == 0 {
+		ndim = 0
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(ndim))
+	hasNullIdx := len(b)
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = binary.BigEndian.AppendUint32(b, elemOID)
+	if ndim == 0 {
+		return b, nil
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(v.Len()))
+	b = binary.BigEndian.AppendUint32(b, 1) // lower bound
+
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Slice && elem.IsNil() {
+			binary.BigEndian.PutUint32(b[hasNullIdx:], 1)
+			b = binary.BigEndian.AppendUint32(b, uint32(nullLength))
+			continue
+		}
+
+		start := len(b)
+		b = append(b, 0, 0, 0, 0)
+		var err error
+		if b, err = appendBinary(b, elem.Interface()); err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint32(b[start:], uint32(len(b)-start-4))
+	}
+	return b, nil
+}
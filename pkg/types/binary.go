@@ -0,0 +1,163 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// BinaryAppender is implemented by types that have a binary wire format.
+type BinaryAppender interface {
+	AppendBinary(b []byte) ([]byte, error)
+}
+
+// binaryOIDer is implemented by generic types whose OID depends on their
+// type parameter.
+type binaryOIDer interface {
+	binaryOID() uint32
+}
+
+// BinaryOID returns the OID of the type v is sent as in binary format, or 0
+// when v has no binary encoding with a fixed type and must be sent as text.
+func BinaryOID(v interface{}) uint32 {
+	switch v := v.(type) {
+	case bool:
+		return BoolOID
+	case int16:
+		return Int2OID
+	case int32:
+		return Int4OID
+	case int64, int:
+		return Int8OID
+	case float32:
+		return Float4OID
+	case float64:
+		return Float8OID
+	case []byte:
+		return ByteaOID
+	case time.Time:
+		return TimestamptzOID
+	case Numeric:
+		return NumericOID
+	case Point:
+		return PointOID
+	case Line:
+		return LineOID
+	case Lseg:
+		return LsegOID
+	case Box:
+		return BoxOID
+	case Path:
+		return PathOID
+	case Polygon:
+		return PolygonOID
+	case Circle:
+		return CircleOID
+	case net.IP, net.IPNet, *net.IPNet, netip.Addr, netip.Prefix:
+		return InetOID
+	case net.HardwareAddr:
+		if len(v) == 8 {
+			return Macaddr8OID
+		}
+		return MacaddrOID
+	case []bool:
+		return BoolArrayOID
+	case []int16:
+		return Int2ArrayOID
+	case []int32:
+		return Int4ArrayOID
+	case []int64, []int:
+		return Int8ArrayOID
+	case []float32:
+		return Float4ArrayOID
+	case []float64:
+		return Float8ArrayOID
+	case []time.Time:
+		return TimestamptzArrayOID
+	case [][]byte:
+		return ByteaArrayOID
+	case []Numeric:
+		return NumericArrayOID
+	case binaryOIDer:
+		return v.binaryOID()
+	}
+	return 0
+}
+
+// AppendBinaryParam appends v in binary format and returns its type OID.
+// ok is false, and b is returned unchanged, when v has no binary encoding
+// and must be sent as text.
+func AppendBinaryParam(b []byte, v interface{}) (data []byte, oid uint32, ok bool, err error) {
+	oid = BinaryOID(v)
+	if oid == 0 {
+		return b, 0, false, nil
+	}
+	data, err = appendBinary(b, v)
+	if err != nil {
+		return b, 0, false, err
+	}
+	return data, oid, true, nil
+}
+
+func appendBinary(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case int16:
+		return binary.BigEndian.AppendUint16(b, uint16(v)), nil
+	case int32:
+		return binary.BigEndian.AppendUint32(b, uint32(v)), nil
+	case int64:
+		return binary.BigEndian.AppendUint64(b, uint64(v)), nil
+	case int:
+		return binary.BigEndian.AppendUint64(b, uint64(v)), nil
+	case float32:
+		return binary.BigEndian.AppendUint32(b, math.Float32bits(v)), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(v)), nil
+	case string:
+		return append(b, v...), nil
+	case []byte:
+		return append(b, v...), nil
+	case time.Time:
+		return AppendTimestampBinary(b, v), nil
+	case net.IP:
+		addr, ok := netip.AddrFromSlice(v)
+		if !ok {
+			return nil, fmt.Errorf("pg: invalid IP address %v", v)
+		}
+		return AppendInetBinary(b, netip.PrefixFrom(addr, addr.Unmap().BitLen()), false), nil
+	case net.IPNet:
+		return appendIPNetBinary(b, v)
+	case *net.IPNet:
+		return appendIPNetBinary(b, *v)
+	case netip.Addr:
+		return AppendInetBinary(b, netip.PrefixFrom(v, v.Unmap().BitLen()), false), nil
+	case netip.Prefix:
+		return AppendInetBinary(b, v, false), nil
+	case net.HardwareAddr:
+		return append(b, v...), nil
+	case map[string]*string:
+		return Hstore(v).AppendBinary(b)
+	case []bool, []int16, []int32, []int64, []int, []float32, []float64, []time.Time, [][]byte, []Numeric:
+		return appendArrayBinary(b, v)
+	case BinaryAppender:
+		return v.AppendBinary(b)
+	}
+	return nil, fmt.Errorf("pg: no binary encoding for %T", v)
+}
+
+func appendIPNetBinary(b []byte, ipNet net.IPNet) ([]byte, error) {
+	addr, ok := netip.AddrFromSlice(ipNet.IP)
+	if !ok {
+		return nil, fmt.Errorf("pg: invalid IP network %v", ipNet)
+	}
+	bits, _ := ipNet.Mask.Size()
+	return AppendInetBinary(b, netip.PrefixFrom(addr, bits), false), nil
+}
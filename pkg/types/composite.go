@@ -0,0 +1,413 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// RecordOID is the OID of anonymous row values such as ROW(1, 'a').
+const RecordOID uint32 = 2249
+
+// CompositeField is an attribute of a composite type.
+type CompositeField struct {
+	Name string
+	OID  uint32
+}
+
+// CompositeType is a composite (row) type resolved on a connection. Values are
+// decoded into the Go type given to RegisterComposite, or into []interface{}.
+type CompositeType struct {
+	Name   string
+	OID    uint32
+	Fields []CompositeField
+
+	typ reflect.Type
+}
+
+var compositeTypes sync.Map
+
+// RegisterComposite registers the Go type of value for the composite type
+// name. Connections look up the OID and attributes of every registered
+// composite in pg_type when they are established, and decode columns of that
+// type into values of the same Go type as value. A nil value decodes into
+// []interface{}. Expecting to be used only during initialization, it panics
+// if name is already registered.
+func RegisterComposite(name string, value interface{}) {
+	var typ reflect.Type
+	if value != nil {
+		typ = reflect.TypeOf(value)
+		if typ.Kind() != reflect.Struct {
+			panic(fmt.Errorf("pg: composite %s must be registered with a struct, got %s", name, typ))
+		}
+	}
+
+	_, loaded := compositeTypes.LoadOrStore(name, compositeGoType{typ})
+	if loaded {
+		panic(fmt.Errorf("pg: composite type=%s is already registered", name))
+	}
+}
+
+// compositeGoType wraps the registered type so that nil can be stored.
+type compositeGoType struct {
+	typ reflect.Type
+}
+
+// RegisteredComposites returns the names given to RegisterComposite.
+func RegisteredComposites() []string {
+	var names []string
+	compositeTypes.Range(func(key, _ interface{}) bool {
+		names = append(names, key.(string))
+		return true
+	})
+	return names
+}
+
+// NewCompositeType returns the composite type name with the given OID and
+// attributes, bound to the Go type registered for name, if any.
+func NewCompositeType(name string, oid uint32, fields []CompositeField) *CompositeType {
+	c := &CompositeType{Name: name, OID: oid, Fields: fields}
+	if v, ok := compositeTypes.Load(name); ok {
+		c.typ = v.(compositeGoType).typ
+	}
+	return c
+}
+
+// Type returns the registry entry for c.
+func (c *CompositeType) Type() *Type {
+	return &Type{OID: c.OID, Name: c.Name, Kind: CompositeKind, Decode: c.Decode}
+}
+
+// Decode decodes src into a new value of the registered Go type, or into
+// []interface{} when no Go type is registered.
+func (c *CompositeType) Decode(src []byte, format int16) (interface{}, error) {
+	if c.typ == nil {
+		var values []interface{}
+		err := c.Scan(&values, src, format)
+		return values, err
+	}
+
+	v := reflect.New(c.typ)
+	if err := c.Scan(v.Interface(), src, format); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// Scan decodes src into dst, a pointer to a struct or to []interface{}.
+// Struct fields are matched to attributes by their pg tag or by name.
+func (c *CompositeType) Scan(dst interface{}, src []byte, format int16) error {
+	names := make([]string, len(c.Fields))
+	oids := make([]uint32, len(c.Fields))
+	for i, f := range c.Fields {
+		names[i] = f.Name
+		oids[i] = f.OID
+	}
+	return scanComposite(dst, src, format, names, oids)
+}
+
+// AppendBinary appends the struct or []interface{} v in the binary composite
+// format, using the attribute OIDs of c.
+func (c *CompositeType) AppendBinary(b []byte, v interface{}) ([]byte, error) {
+	values, err := compositeValues(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(c.Fields) {
+		return nil, fmt.Errorf("pg: composite %s has %d attributes, got %d values", c.Name, len(c.Fields), len(values))
+	}
+
+	b = binary.BigEndian.AppendUint32(b, uint32(len(values)))
+	for i, value := range values {
+		b = binary.BigEndian.AppendUint32(b, c.Fields[i].OID)
+		if value == nil {
+			b = binary.BigEndian.AppendUint32(b, uint32(nullLength))
+			continue
+		}
+		start := len(b)
+		b = append(b, 0, 0, 0, 0)
+		if b, err = appendBinary(b, value); err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint32(b[start:], uint32(len(b)-start-4))
+	}
+	return b, nil
+}
+
+// ScanComposite decodes an anonymous composite value into dst, a pointer to a
+// struct or to []interface{}. Struct fields are matched by position.
+func ScanComposite(dst interface{}, src []byte, format int16) error {
+	return scanComposite(dst, src, format, nil, nil)
+}
+
+var nullLength = int32(-1)
+
+type compositeElem struct {
+	oid uint32
+	src []byte // nil for NULL
+}
+
+func scanComposite(dst interface{}, src []byte, format int16, names []string, oids []uint32) error {
+	if src == nil {
+		return fmt.Errorf("pg: can't scan NULL into %T", dst)
+	}
+
+	var (
+		elems []compositeElem
+		err   error
+	)
+	if format == BinaryFormat {
+		elems, err = decodeCompositeBinary(src)
+	} else {
+		elems, err = parseCompositeText(string(src))
+		for i := range elems {
+			if i < len(oids) {
+				elems[i].oid = oids[i]
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if values, ok := dst.(*[]interface{}); ok {
+		*values = make([]interface{}, len(elems))
+		for i, elem := range elems {
+			if format == TextFormat && elem.oid == 0 {
+				if elem.src != nil {
+					(*values)[i] = string(elem.src)
+				}
+				continue
+			}
+			if (*values)[i], err = DecodeValue(elem.oid, elem.src, format); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("pg: can't scan composite into %T", dst)
+	}
+	v = v.Elem()
+
+	fields := compositeStructFields(v.Type())
+	for i, elem := range elems {
+		idx := i
+		if i < len(names) {
+			idx = fieldIndexByName(fields, names[i])
+		}
+		if idx < 0 || idx >= len(fields) {
+			continue
+		}
+		if err := scanValue(v.FieldByIndex(fields[idx].Index), elem.src, format); err != nil {
+			return fmt.Errorf("pg: composite field %s: %w", fields[idx].Name, err)
+		}
+	}
+	return nil
+}
+
+// decodeCompositeBinary splits the binary composite format: the number of
+// fields followed by the OID and length-prefixed value of each field.
+func decodeCompositeBinary(src []byte) ([]compositeElem, error) {
+	if len(src) < 4 {
+		return nil, fmt.Errorf("pg: invalid binary composite length %d", len(src))
+	}
+	count := int(binary.BigEndian.Uint32(src))
+	src = src[4:]
+
+	elems := make([]compositeElem, 0, count)
+	for i := 0; i < count; i++ {
+		if len(src) < 8 {
+			return nil, fmt.Errorf("pg: truncated binary composite")
+		}
+		elem := compositeElem{oid: binary.BigEndian.Uint32(src)}
+		n := int(int32(binary.BigEndian.Uint32(src[4:])))
+		src = src[8:]
+		if n >= 0 {
+			if len(src) < n {
+				return nil, fmt.Errorf("pg: truncated binary composite")
+			}
+			elem.src = src[:n:n]
+			src = src[n:]
+		}
+		elems = append(elems, elem)
+	}
+	return elems, nil
+}
+
+// parseCompositeText splits the text composite format. An empty unquoted
+// field is NULL, "" is an empty string, and both "" and backslash escape a
+// character inside quotes.
+func parseCompositeText(s string) ([]compositeElem, error) {
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return nil, fmt.Errorf("pg: invalid composite %q", s)
+	}
+	s = s[1 : len(s)-1]
+
+	var (
+		elems   []compositeElem
+		buf     []byte
+		quoted  bool
+		present bool
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, fmt.Errorf("pg: invalid composite %q", s)
+			}
+			i++
+			buf = append(buf, s[i])
+			present = true
+		case c == '"':
+			if quoted && i+1 < len(s) && s[i+1] == '"' {
+				buf = append(buf, '"')
+				i++
+			} else {
+				quoted = !quoted
+			}
+			present = true
+		case c == ',' && !quoted:
+			elems = append(elems, newCompositeTextElem(buf, present))
+			buf, present = nil, false
+		default:
+			buf = append(buf, c)
+			present = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("pg: invalid composite %q", s)
+	}
+	return append(elems, newCompositeTextElem(buf, present)), nil
+}
+
+func newCompositeTextElem(buf []byte, present bool) compositeElem {
+	if !present {
+		return compositeElem{}
+	}
+	if buf == nil {
+		buf = []byte{}
+	}
+	return compositeElem{src: buf}
+}
+
+func compositeStructFields(typ reflect.Type) []reflect.StructField {
+	fields := make([]reflect.StructField, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() || f.Tag.Get("pg") == "-" {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func fieldIndexByName(fields []reflect.StructField, name string) int {
+	for i, f := range fields {
+		if tag := f.Tag.Get("pg"); tag != "" {
+			if tag == name {
+				return i
+			}
+			continue
+		}
+		if strings.EqualFold(strings.ReplaceAll(name, "_", ""), f.Name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// compositeValues returns the attribute values of a struct or []interface{},
+// with nil for NULL.
+func compositeValues(v interface{}) ([]interface{}, error) {
+	if values, ok := v.([]interface{}); ok {
+		return values, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("pg: can't use %T as a composite", v)
+	}
+
+	fields := compositeStructFields(rv.Type())
+	values := make([]interface{}, len(fields))
+	for i, f := range fields {
+		fv := rv.FieldByIndex(f.Index)
+		for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if (fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface) && fv.IsNil() {
+			continue
+		}
+		values[i] = fv.Interface()
+	}
+	return values, nil
+}
+
+func appendStructValue(b []byte, v reflect.Value, flags int) []byte {
+	values, err := compositeValues(v.Interface())
+	if err != nil {
+		return AppendError(b, err)
+	}
+	return appendComposite(b, values, flags)
+}
+
+// appendComposite appends values in the text composite format.
+func appendComposite(b []byte, values []interface{}, flags int) []byte {
+	quote := hasFlag(flags, quoteFlag) && !hasFlag(flags, arrayFlag)
+	if quote {
+		b = append(b, '\'')
+	}
+
+	b = append(b, '(')
+	for i, value := range values {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		field := Append([]byte{}, value, 0)
+		if field == nil {
+			continue
+		}
+		b = appendCompositeField(b, field, quote)
+	}
+	b = append(b, ')')
+
+	if quote {
+		b = append(b, '\'')
+	}
+	return b
+}
+
+func appendCompositeField(b []byte, field []byte, quote bool) []byte {
+	if len(field) > 0 && !strings.ContainsAny(string(field), "\"\\,() \t\n\r'") {
+		return append(b, field...)
+	}
+
+	b = append(b, '"')
+	for _, c := range field {
+		switch c {
+		case '"', '\\':
+			b = append(b, c, c)
+		case '\'':
+			if quote {
+				b = append(b, '\'')
+			}
+			b = append(b, c)
+		default:
+			b = append(b, c)
+		}
+	}
+	return append(b, '"')
+}
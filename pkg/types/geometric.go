@@ -0,0 +1,440 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// OIDs of the built-in geometric types.
+const (
+	PointOID   uint32 = 600
+	LsegOID    uint32 = 601
+	PathOID    uint32 = 602
+	BoxOID     uint32 = 603
+	PolygonOID uint32 = 604
+	LineOID    uint32 = 628
+	CircleOID  uint32 = 718
+)
+
+// Point is a point value, (x,y).
+type Point struct {
+	X, Y float64
+}
+
+// Line is an infinite line value, {A,B,C} for Ax + By + C = 0.
+type Line struct {
+	A, B, C float64
+}
+
+// Lseg is a line segment value, [(x1,y1),(x2,y2)].
+type Lseg struct {
+	P [2]Point
+}
+
+// Box is a box value, (x1,y1),(x2,y2). PostgreSQL stores the upper right
+// corner first and the lower left corner second.
+type Box struct {
+	P [2]Point
+}
+
+// Path is a path value, open [(x1,y1),...] or closed ((x1,y1),...).
+type Path struct {
+	Points []Point
+	Closed bool
+}
+
+// Polygon is a polygon value, ((x1,y1),...).
+type Polygon struct {
+	Points []Point
+}
+
+// Circle is a circle value, <(x,y),r>.
+type Circle struct {
+	Center Point
+	Radius float64
+}
+
+var (
+	_ ValueAppender  = (*Point)(nil)
+	_ ValueScanner   = (*Point)(nil)
+	_ BinaryAppender = (*Point)(nil)
+	_ ValueAppender  = (*Line)(nil)
+	_ ValueScanner   = (*Line)(nil)
+	_ BinaryAppender = (*Line)(nil)
+	_ ValueAppender  = (*Lseg)(nil)
+	_ ValueScanner   = (*Lseg)(nil)
+	_ BinaryAppender = (*Lseg)(nil)
+	_ ValueAppender  = (*Box)(nil)
+	_ ValueScanner   = (*Box)(nil)
+	_ BinaryAppender = (*Box)(nil)
+	_ ValueAppender  = (*Path)(nil)
+	_ ValueScanner   = (*Path)(nil)
+	_ BinaryAppender = (*Path)(nil)
+	_ ValueAppender  = (*Polygon)(nil)
+	_ ValueScanner   = (*Polygon)(nil)
+	_ BinaryAppender = (*Polygon)(nil)
+	_ ValueAppender  = (*Circle)(nil)
+	_ ValueScanner   = (*Circle)(nil)
+	_ BinaryAppender = (*Circle)(nil)
+)
+
+//------------------------------------------------------------------------------
+
+func (p Point) AppendValue(b []byte, flags int) ([]byte, error) {
+	return appendGeometric(b, flags, func(b []byte) []byte {
+		return appendPointText(b, p)
+	}), nil
+}
+
+func (p Point) AppendBinary(b []byte) ([]byte, error) {
+	return appendFloat64s(b, p.X, p.Y), nil
+}
+
+func (p *Point) ScanValue(src []byte, format int16) error {
+	f, err := decodeGeometric(src, format, "point", 2)
+	if err != nil {
+		return err
+	}
+	*p = Point{f[0], f[1]}
+	return nil
+}
+
+func (l Line) AppendValue(b []byte, flags int) ([]byte, error) {
+	return appendGeometric(b, flags, func(b []byte) []byte {
+		b = append(b, '{')
+		b = appendGeometricFloat(b, l.A)
+		b = append(b, ',')
+		b = appendGeometricFloat(b, l.B)
+		b = append(b, ',')
+		b = appendGeometricFloat(b, l.C)
+		return append(b, '}')
+	}), nil
+}
+
+func (l Line) AppendBinary(b []byte) ([]byte, error) {
+	return appendFloat64s(b, l.A, l.B, l.C), nil
+}
+
+func (l *Line) ScanValue(src []byte, format int16) error {
+	f, err := decodeGeometric(src, format, "line", 3)
+	if err != nil {
+		return err
+	}
+	*l = Line{f[0], f[1], f[2]}
+	return nil
+}
+
+func (l Lseg) AppendValue(b []byte, flags int) ([]byte, error) {
+	return appendGeometric(b, flags, func(b []byte) []byte {
+		b = append(b, '[')
+		b = appendPointsText(b, l.P[:])
+		return append(b, ']')
+	}), nil
+}
+
+func (l Lseg) AppendBinary(b []byte) ([]byte, error) {
+	return appendFloat64s(b, l.P[0].X, l.P[0].Y, l.P[1].X, l.P[1].Y), nil
+}
+
+func (l *Lseg) ScanValue(src []byte, format int16) error {
+	f, err := decodeGeometric(src, format, "lseg", 4)
+	if err != nil {
+		return err
+	}
+	*l = Lseg{[2]Point{{f[0], f[1]}, {f[2], f[3]}}}
+	return nil
+}
+
+func (bx Box) AppendValue(b []byte, flags int) ([]byte, error) {
+	return appendGeometric(b, flags, func(b []byte) []byte {
+		return appendPointsText(b, bx.P[:])
+	}), nil
+}
+
+func (bx Box) AppendBinary(b []byte) ([]byte, error) {
+	return appendFloat64s(b, bx.P[0].X, bx.P[0].Y, bx.P[1].X, bx.P[1].Y), nil
+}
+
+func (bx *Box) ScanValue(src []byte, format int16) error {
+	f, err := decodeGeometric(src, format, "box", 4)
+	if err != nil {
+		return err
+	}
+	*bx = Box{[2]Point{{f[0], f[1]}, {f[2], f[3]}}}
+	return nil
+}
+
+func (p Path) AppendValue(b []byte, flags int) ([]byte, error) {
+	return appendGeometric(b, flags, func(b []byte) []byte {
+		if p.Closed {
+			b = append(b, '(')
+		} else {
+			b = append(b, '[')
+		}
+		b = appendPointsText(b, p.Points)
+		if p.Closed {
+			return append(b, ')')
+		}
+		return append(b, ']')
+	}), nil
+}
+
+// AppendBinary appends p in the binary path format: a closed flag byte and
+// the number of points followed by the points.
+func (p Path) AppendBinary(b []byte) ([]byte, error) {
+	if p.Closed {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	return appendPointsBinary(b, p.Points), nil
+}
+
+func (p *Path) ScanValue(src []byte, format int16) error {
+	if src == nil {
+		return fmt.Errorf("pg: can't scan NULL into path")
+	}
+
+	if format == BinaryFormat {
+		if len(src) < 1 {
+			return fmt.Errorf("pg: invalid binary path length %d", len(src))
+		}
+		points, err := decodePointsBinary(src[1:], "path")
+		if err != nil {
+			return err
+		}
+		*p = Path{Points: points, Closed: src[0] != 0}
+		return nil
+	}
+
+	s := strings.TrimSpace(string(src))
+	points, err := parsePoints(s, "path")
+	if err != nil {
+		return err
+	}
+	*p = Path{Points: points, Closed: !strings.HasPrefix(s, "[")}
+	return nil
+}
+
+func (p Polygon) AppendValue(b []byte, flags int) ([]byte, error) {
+	return appendGeometric(b, flags, func(b []byte) []byte {
+		b = append(b, '(')
+		b = appendPointsText(b, p.Points)
+		return append(b, ')')
+	}), nil
+}
+
+// AppendBinary appends p in the binary polygon format: the number of points
+// followed by the points.
+func (p Polygon) AppendBinary(b []byte) ([]byte, error) {
+	return appendPointsBinary(b, p.Points), nil
+}
+
+func (p *Polygon) ScanValue(src []byte, format int16) error {
+	if src == nil {
+		return fmt.Errorf("pg: can't scan NULL into polygon")
+	}
+
+	var (
+		points []Point
+		err    error
+	)
+	if format == BinaryFormat {
+		points, err = decodePointsBinary(src, "polygon")
+	} else {
+		points, err = parsePoints(string(src), "polygon")
+	}
+	if err != nil {
+		return err
+	}
+	*p = Polygon{Points: points}
+	return nil
+}
+
+func (c Circle) AppendValue(b []byte, flags int) ([]byte, error) {
+	return appendGeometric(b, flags, func(b []byte) []byte {
+		b = append(b, '<')
+		b = appendPointText(b, c.Center)
+		b = append(b, ',')
+		b = appendGeometricFloat(b, c.Radius)
+		return append(b, '>')
+	}), nil
+}
+
+func (c Circle) AppendBinary(b []byte) ([]byte, error) {
+	return appendFloat64s(b, c.Center.X, c.Center.Y, c.Radius), nil
+}
+
+func (c *Circle) ScanValue(src []byte, format int16) error {
+	f, err := decodeGeometric(src, format, "circle", 3)
+	if err != nil {
+		return err
+	}
+	*c = Circle{Center: Point{f[0], f[1]}, Radius: f[2]}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func appendGeometric(b []byte, flags int, fn func([]byte) []byte) []byte {
+	quote := hasFlag(flags, quoteFlag) && !hasFlag(flags, arrayFlag)
+	if quote {
+		b = append(b, '\'')
+	}
+	b = fn(b)
+	if quote {
+		b = append(b, '\'')
+	}
+	return b
+}
+
+func appendGeometricFloat(b []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendFloat2(b, f, 0)
+	}
+	return strconv.AppendFloat(b, f, 'g', -1, 64)
+}
+
+func appendPointText(b []byte, p Point) []byte {
+	b = append(b, '(')
+	b = appendGeometricFloat(b, p.X)
+	b = append(b, ',')
+	b = appendGeometricFloat(b, p.Y)
+	return append(b, ')')
+}
+
+func appendPointsText(b []byte, points []Point) []byte {
+	for i, p := range points {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendPointText(b, p)
+	}
+	return b
+}
+
+func appendFloat64s(b []byte, values ...float64) []byte {
+	for _, f := range values {
+		b = binary.BigEndian.AppendUint64(b, math.Float64bits(f))
+	}
+	return b
+}
+
+func appendPointsBinary(b []byte, points []Point) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(points)))
+	for _, p := range points {
+		b = appendFloat64s(b, p.X, p.Y)
+	}
+	return b
+}
+
+// decodeGeometric decodes a fixed-size geometric value made of n float8.
+func decodeGeometric(src []byte, format int16, name string, n int) ([]float64, error) {
+	if src == nil {
+		return nil, fmt.Errorf("pg: can't scan NULL into %s", name)
+	}
+
+	if format == BinaryFormat {
+		if len(src) != 8*n {
+			return nil, fmt.Errorf("pg: invalid binary %s length %d", name, len(src))
+		}
+		return decodeFloat64s(src, n), nil
+	}
+
+	f, err := parseGeometricFloats(string(src), name)
+	if err != nil {
+		return nil, err
+	}
+	if len(f) != n {
+		return nil, fmt.Errorf("pg: invalid %s %q", name, src)
+	}
+	return f, nil
+}
+
+func decodeFloat64s(src []byte, n int) []float64 {
+	f := make([]float64, n)
+	for i := range f {
+		f[i] = math.Float64frombits(binary.BigEndian.Uint64(src[8*i:]))
+	}
+	return f
+}
+
+func decodePointsBinary(src []byte, name string) ([]Point, error) {
+	if len(src) < 4 {
+		return nil, fmt.Errorf("pg: invalid binary %s length %d", name, len(src))
+	}
+	n := int(binary.BigEndian.Uint32(src))
+	if len(src) != 4+16*n {
+		return nil, fmt.Errorf("pg: invalid binary %s length %d for %d points", name, len(src), n)
+	}
+	f := decodeFloat64s(src[4:], 2*n)
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{f[2*i], f[2*i+1]}
+	}
+	return points, nil
+}
+
+func parsePoints(s string, name string) ([]Point, error) {
+	f, err := parseGeometricFloats(s, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(f)%2 != 0 {
+		return nil, fmt.Errorf("pg: invalid %s %q", name, s)
+	}
+	points := make([]Point, len(f)/2)
+	for i := range points {
+		points[i] = Point{f[2*i], f[2*i+1]}
+	}
+	return points, nil
+}
+
+// parseGeometricFloats returns the numbers of a geometric text value,
+// ignoring the delimiters around them.
+func parseGeometricFloats(s string, name string) ([]float64, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune("()[]{}<>, \t\n\r", r)
+	})
+
+	f := make([]float64, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("pg: invalid %s %q", name, s)
+		}
+		f[i] = v
+	}
+	return f, nil
+}
+
+func decodeGeometricValue(oid uint32, src []byte, format int16) (interface{}, error) {
+	var v ValueScanner
+	switch oid {
+	case PointOID:
+		v = new(Point)
+	case LineOID:
+		v = new(Line)
+	case LsegOID:
+		v = new(Lseg)
+	case BoxOID:
+		v = new(Box)
+	case PathOID:
+		v = new(Path)
+	case PolygonOID:
+		v = new(Polygon)
+	case CircleOID:
+		v = new(Circle)
+	default:
+		return nil, fmt.Errorf("pg: OID %d is not a geometric type", oid)
+	}
+
+	if err := v.ScanValue(src, format); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(v).Elem().Interface(), nil
+}
@@ -0,0 +1,303 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Hstore is a value of the hstore extension type. A nil value is NULL.
+type Hstore map[string]*string
+
+var (
+	_ ValueAppender  = (*Hstore)(nil)
+	_ ValueScanner   = (*Hstore)(nil)
+	_ BinaryAppender = (*Hstore)(nil)
+)
+
+var hstoreMapType = reflect.TypeOf(map[string]*string(nil))
+
+// NewHstoreType returns the registry entry for hstore, whose OID differs
+// between databases.
+func NewHstoreType(oid, arrayOID uint32) *Type {
+	return &Type{
+		OID:      oid,
+		Name:     "hstore",
+		Kind:     BaseKind,
+		ArrayOID: arrayOID,
+		Decode: func(src []byte, format int16) (interface{}, error) {
+			var h Hstore
+			if err := h.ScanValue(src, format); err != nil {
+				return nil, err
+			}
+			return map[string]*string(h), nil
+		},
+	}
+}
+
+func (h Hstore) AppendValue(b []byte, flags int) ([]byte, error) {
+	if h == nil {
+		return AppendNull(b, flags), nil
+	}
+	if err := h.validate(); err != nil {
+		return nil, err
+	}
+
+	quote := hasFlag(flags, quoteFlag) && !hasFlag(flags, arrayFlag)
+	if quote {
+		b = append(b, '\'')
+	}
+
+	for i, key := range h.sortedKeys() {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = appendHstoreString(b, key, quote)
+		b = append(b, "=>"...)
+		if value := h[key]; value != nil {
+			b = appendHstoreString(b, *value, quote)
+		} else {
+			b = append(b, "NULL"...)
+		}
+	}
+
+	if quote {
+		b = append(b, '\'')
+	}
+	return b, nil
+}
+
+func appendHstoreString(b []byte, s string, quote bool) []byte {
+	b = append(b, '"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			b = append(b, '\\', c)
+		case '\'':
+			if quote {
+				b = append(b, '\'')
+			}
+			b = append(b, c)
+		default:
+			b = append(b, c)
+		}
+	}
+	return append(b, '"')
+}
+
+// AppendBinary appends h in the binary hstore format: the number of pairs
+// followed by each length-prefixed key and value, -1 marking a NULL value.
+func (h Hstore) AppendBinary(b []byte) ([]byte, error) {
+	if err := h.validate(); err != nil {
+		return nil, err
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(len(h)))
+	for _, key := range h.sortedKeys() {
+		b = binary.BigEndian.AppendUint32(b, uint32(len(key)))
+		b = append(b, key...)
+		if value := h[key]; value != nil {
+			b = binary.BigEndian.AppendUint32(b, uint32(len(*value)))
+			b = append(b, *value...)
+		} else {
+			b = binary.BigEndian.AppendUint32(b, uint32(nullLength))
+		}
+	}
+	return b, nil
+}
+
+// validate reports an error when a key or value of h contains a NUL byte,
+// which PostgreSQL can't store in text.
+func (h Hstore) validate() error {
+	for key, value := range h {
+		if strings.IndexByte(key, 0) != -1 {
+			return fmt.Errorf("pg: hstore key %q contains a NUL byte", key)
+		}
+		if value != nil && strings.IndexByte(*value, 0) != -1 {
+			return fmt.Errorf("pg: hstore value of key %q contains a NUL byte", key)
+		}
+	}
+	return nil
+}
+
+func (h Hstore) sortedKeys() []string {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (h *Hstore) ScanValue(src []byte, format int16) error {
+	if src == nil {
+		*h = nil
+		return nil
+	}
+
+	var (
+		v   Hstore
+		err error
+	)
+	if format == BinaryFormat {
+		v, err = decodeHstoreBinary(src)
+	} else {
+		v, err = parseHstore(string(src))
+	}
+	if err != nil {
+		return err
+	}
+
+	*h = v
+	return nil
+}
+
+func decodeHstoreBinary(src []byte) (Hstore, error) {
+	if len(src) < 4 {
+		return nil, fmt.Errorf("pg: invalid binary hstore length %d", len(src))
+	}
+	count := int(int32(binary.BigEndian.Uint32(src)))
+	src = src[4:]
+
+	h := make(Hstore, count)
+	for i := 0; i < count; i++ {
+		key, rest, err := readHstoreBinaryString(src)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			return nil, fmt.Errorf("pg: NULL key in binary hstore")
+		}
+		value, rest, err := readHstoreBinaryString(rest)
+		if err != nil {
+			return nil, err
+		}
+		h[*key] = value
+		src = rest
+	}
+	return h, nil
+}
+
+func readHstoreBinaryString(src []byte) (*string, []byte, error) {
+	if len(src) < 4 {
+		return nil, nil, fmt.Errorf("pg: truncated binary hstore")
+	}
+	n := int(int32(binary.BigEndian.Uint32(src)))
+	src = src[4:]
+	if n < 0 {
+		return nil, src, nil
+	}
+	if len(src) < n {
+		return nil, nil, fmt.Errorf("pg: truncated binary hstore")
+	}
+	s := string(src[:n])
+	return &s, src[n:], nil
+}
+
+// parseHstore parses the text hstore format, "key"=>"value" pairs separated
+// by commas. Unquoted NULL values are NULL.
+func parseHstore(s string) (Hstore, error) {
+	h := make(Hstore)
+	p := hstoreParser{s: s}
+
+	for {
+		p.skipSpaces()
+		if p.done() {
+			return h, nil
+		}
+
+		key, quoted, err := p.readString()
+		if err != nil {
+			return nil, err
+		}
+		if !quoted && key == "" {
+			return nil, fmt.Errorf("pg: invalid hstore %q", s)
+		}
+
+		p.skipSpaces()
+		if !strings.HasPrefix(p.s[p.pos:], "=>") {
+			return nil, fmt.Errorf("pg: invalid hstore %q", s)
+		}
+		p.pos += 2
+		p.skipSpaces()
+
+		value, quoted, err := p.readString()
+		if err != nil {
+			return nil, err
+		}
+		if !quoted && strings.EqualFold(value, "NULL") {
+			h[key] = nil
+		} else {
+			h[key] = &value
+		}
+
+		p.skipSpaces()
+		if p.done() {
+			return h, nil
+		}
+		if p.s[p.pos] != ',' {
+			return nil, fmt.Errorf("pg: invalid hstore %q", s)
+		}
+		p.pos++
+	}
+}
+
+type hstoreParser struct {
+	s   string
+	pos int
+}
+
+func (p *hstoreParser) done() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *hstoreParser) skipSpaces() {
+	for !p.done() && strings.IndexByte(" \t\n\r", p.s[p.pos]) != -1 {
+		p.pos++
+	}
+}
+
+// readString reads a quoted string or an unquoted word ending at a space,
+// '=' or ','.
+func (p *hstoreParser) readString() (string, bool, error) {
+	var sb strings.Builder
+
+	if !p.done() && p.s[p.pos] == '"' {
+		p.pos++
+		for !p.done() {
+			c := p.s[p.pos]
+			p.pos++
+			switch c {
+			case '"':
+				return sb.String(), true, nil
+			case '\\':
+				if p.done() {
+					return "", false, fmt.Errorf("pg: invalid hstore %q", p.s)
+				}
+				sb.WriteByte(p.s[p.pos])
+				p.pos++
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		return "", false, fmt.Errorf("pg: invalid hstore %q", p.s)
+	}
+
+	for !p.done() && strings.IndexByte(" \t\n\r=,", p.s[p.pos]) == -1 {
+		if p.s[p.pos] == '\\' && p.pos+1 < len(p.s) {
+			p.pos++
+		}
+		sb.WriteByte(p.s[p.pos])
+		p.pos++
+	}
+	return sb.String(), false, nil
+}
+
+func appendHstoreValue(b []byte, v reflect.Value, flags int) []byte {
+	bb, err := Hstore(v.Interface().(map[string]*string)).AppendValue(b, flags)
+	if err != nil {
+		return AppendError(b, err)
+	}
+	return bb
+}
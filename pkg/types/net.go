@@ -0,0 +1,182 @@
+package types
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+	"strings"
+)
+
+// OIDs of the built-in network address types.
+const (
+	CidrOID     uint32 = 650
+	MacaddrOID  uint32 = 829
+	InetOID     uint32 = 869
+	Macaddr8OID uint32 = 774
+)
+
+// Address families of the binary inet format, from inet.h.
+const (
+	pgsqlAfInet  = 2
+	pgsqlAfInet6 = 3
+)
+
+var (
+	ipType           = reflect.TypeOf(net.IP(nil))
+	ipNetType        = reflect.TypeOf(net.IPNet{})
+	hardwareAddrType = reflect.TypeOf(net.HardwareAddr(nil))
+	netipAddrType    = reflect.TypeOf(netip.Addr{})
+	netipPrefixType  = reflect.TypeOf(netip.Prefix{})
+)
+
+// ParseInet decodes an inet or cidr value. The address keeps its host bits,
+// so 192.168.1.5/24 is returned as is.
+func ParseInet(src []byte, format int16) (netip.Prefix, error) {
+	if format == BinaryFormat {
+		return decodeInetBinary(src)
+	}
+
+	s := string(src)
+	if strings.IndexByte(s, '/') == -1 {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("pg: invalid inet %q", s)
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("pg: invalid inet %q", s)
+	}
+	return prefix, nil
+}
+
+// decodeInetBinary decodes the binary inet format: family, bits, is_cidr and
+// address length bytes followed by the address.
+func decodeInetBinary(src []byte) (netip.Prefix, error) {
+	if len(src) < 4 {
+		return netip.Prefix{}, fmt.Errorf("pg: invalid binary inet length %d", len(src))
+	}
+
+	family, bits, n := src[0], int(src[1]), int(src[3])
+	if len(src) != 4+n {
+		return netip.Prefix{}, fmt.Errorf("pg: invalid binary inet length %d", len(src))
+	}
+
+	var addr netip.Addr
+	switch {
+	case family == pgsqlAfInet && n == 4:
+		addr = netip.AddrFrom4([4]byte(src[4:8]))
+	case family == pgsqlAfInet6 && n == 16:
+		addr = netip.AddrFrom16([16]byte(src[4:20]))
+	default:
+		return netip.Prefix{}, fmt.Errorf("pg: invalid binary inet family %d", family)
+	}
+
+	if bits > addr.BitLen() {
+		return netip.Prefix{}, fmt.Errorf("pg: invalid binary inet bits %d", bits)
+	}
+	return netip.PrefixFrom(addr, bits), nil
+}
+
+// AppendInetBinary appends prefix in the binary inet format. isCIDR must be
+// true for cidr parameters, whose host bits must be zero.
+func AppendInetBinary(b []byte, prefix netip.Prefix, isCIDR bool) []byte {
+	addr := prefix.Addr().Unmap()
+	family := byte(pgsqlAfInet)
+	if addr.Is6() {
+		family = pgsqlAfInet6
+	}
+
+	bits := prefix.Bits()
+	if bits < 0 || bits > addr.BitLen() {
+		bits = addr.BitLen()
+	}
+
+	var cidr byte
+	if isCIDR {
+		cidr = 1
+	}
+
+	addrBytes := addr.AsSlice()
+	b = append(b, family, byte(bits), cidr, byte(len(addrBytes)))
+	return append(b, addrBytes...)
+}
+
+// ParseMacaddr decodes a macaddr or macaddr8 value.
+func ParseMacaddr(src []byte, format int16) (net.HardwareAddr, error) {
+	if format == BinaryFormat {
+		if len(src) != 6 && len(src) != 8 {
+			return nil, fmt.Errorf("pg: invalid binary macaddr length %d", len(src))
+		}
+		return append(net.HardwareAddr(nil), src...), nil
+	}
+
+	addr, err := net.ParseMAC(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("pg: invalid macaddr %q", src)
+	}
+	return addr, nil
+}
+
+// scanNetValue scans into the net and netip types, ok is false for any other
+// type.
+func scanNetValue(v reflect.Value, src []byte, format int16) (ok bool, err error) {
+	switch v.Type() {
+	case ipType:
+		prefix, err := ParseInet(src, format)
+		if err != nil {
+			return true, err
+		}
+		v.Set(reflect.ValueOf(net.IP(prefix.Addr().Unmap().AsSlice())))
+	case ipNetType:
+		prefix, err := ParseInet(src, format)
+		if err != nil {
+			return true, err
+		}
+		addr := prefix.Addr().Unmap()
+		ipNet := net.IPNet{
+			IP:   net.IP(addr.AsSlice()),
+			Mask: net.CIDRMask(prefix.Bits(), addr.BitLen()),
+		}
+		v.Set(reflect.ValueOf(ipNet))
+	case netipPrefixType:
+		prefix, err := ParseInet(src, format)
+		if err != nil {
+			return true, err
+		}
+		v.Set(reflect.ValueOf(prefix))
+	case netipAddrType:
+		prefix, err := ParseInet(src, format)
+		if err != nil {
+			return true, err
+		}
+		v.Set(reflect.ValueOf(prefix.Addr()))
+	case hardwareAddrType:
+		addr, err := ParseMacaddr(src, format)
+		if err != nil {
+			return true, err
+		}
+		v.Set(reflect.ValueOf(addr))
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func appendNetipAddrValue(b []byte, v reflect.Value, flags int) []byte {
+	addr := v.Interface().(netip.Addr)
+	return AppendString(b, addr.String(), flags)
+}
+
+func appendNetipPrefixValue(b []byte, v reflect.Value, flags int) []byte {
+	prefix := v.Interface().(netip.Prefix)
+	return AppendString(b, prefix.String(), flags)
+}
+
+func appendHardwareAddrValue(b []byte, v reflect.Value, flags int) []byte {
+	addr := v.Interface().(net.HardwareAddr)
+	return AppendString(b, addr.String(), flags)
+}
@@ -0,0 +1,336 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	numericPos  = 0x0000
+	numericNeg  = 0x4000
+	numericNaN  = 0xC000
+	numericPInf = 0xD000
+	numericNInf = 0xF000
+
+	numericBase = 10000
+)
+
+var (
+	bigTen         = big.NewInt(10)
+	bigNumericBase = big.NewInt(numericBase)
+)
+
+// Numeric is an arbitrary-precision PostgreSQL NUMERIC value.
+// A finite value equals Int * 10^-Scale.
+type Numeric struct {
+	Int   *big.Int
+	Scale int32
+	NaN   bool
+	Inf   int8 // 1 for Infinity, -1 for -Infinity
+}
+
+var (
+	_ ValueAppender = (*Numeric)(nil)
+	_ ValueScanner  = (*Numeric)(nil)
+)
+
+// NumericFromBigInt returns the integer i as a Numeric with scale 0.
+func NumericFromBigInt(i *big.Int) Numeric {
+	return Numeric{Int: new(big.Int).Set(i)}
+}
+
+// NumericFromRat returns r rounded half away from zero to scale decimal digits.
+func NumericFromRat(r *big.Rat, scale int32) Numeric {
+	if scale < 0 {
+		scale = 0
+	}
+	num := new(big.Int).Mul(r.Num(), pow10(scale))
+	quo, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	rem.Abs(rem).Lsh(rem, 1)
+	if rem.Cmp(r.Denom()) >= 0 {
+		if r.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return Numeric{Int: quo, Scale: scale}
+}
+
+// ParseNumeric parses the text representation of a NUMERIC, including
+// NaN, Infinity, -Infinity and exponent notation.
+func ParseNumeric(s string) (Numeric, error) {
+	switch s {
+	case "NaN":
+		return Numeric{NaN: true}, nil
+	case "Infinity", "+Infinity", "inf", "+inf":
+		return Numeric{Inf: 1}, nil
+	case "-Infinity", "-inf":
+		return Numeric{Inf: -1}, nil
+	}
+
+	mantissa, exp := s, int64(0)
+	if idx := strings.IndexAny(s, "eE"); idx != -1 {
+		e, err := strconv.ParseInt(s[idx+1:], 10, 32)
+		if err != nil {
+			return Numeric{}, fmt.Errorf("pg: invalid numeric %q", s)
+		}
+		mantissa, exp = s[:idx], e
+	}
+
+	scale := int64(0)
+	if idx := strings.IndexByte(mantissa, '.'); idx != -1 {
+		scale = int64(len(mantissa) - idx - 1)
+		mantissa = mantissa[:idx] + mantissa[idx+1:]
+	}
+
+	digits := strings.TrimLeft(mantissa, "+-")
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return Numeric{}, fmt.Errorf("pg: invalid numeric %q", s)
+	}
+
+	i, ok := new(big.Int).SetString(mantissa, 10)
+	if !ok {
+		return Numeric{}, fmt.Errorf("pg: invalid numeric %q", s)
+	}
+
+	scale -= exp
+	if scale < 0 {
+		i.Mul(i, pow10(int32(-scale)))
+		scale = 0
+	}
+
+	return Numeric{Int: i, Scale: int32(scale)}, nil
+}
+
+// DecodeNumericBinary decodes the binary NUMERIC wire format: ndigits, weight,
+// sign and dscale followed by ndigits base-10000 digits.
+func DecodeNumericBinary(src []byte) (Numeric, error) {
+	if len(src) < 8 {
+		return Numeric{}, fmt.Errorf("pg: invalid binary numeric length %d", len(src))
+	}
+
+	ndigits := int(int16(binary.BigEndian.Uint16(src[0:])))
+	weight := int(int16(binary.BigEndian.Uint16(src[2:])))
+	sign := binary.BigEndian.Uint16(src[4:])
+	dscale := int32(binary.BigEndian.Uint16(src[6:]))
+
+	switch sign {
+	case numericNaN:
+		return Numeric{NaN: true}, nil
+	case numericPInf:
+		return Numeric{Inf: 1}, nil
+	case numericNInf:
+		return Numeric{Inf: -1}, nil
+	case numericPos, numericNeg:
+	default:
+		return Numeric{}, fmt.Errorf("pg: invalid binary numeric sign 0x%04x", sign)
+	}
+
+	if ndigits < 0 || len(src) != 8+2*ndigits {
+		return Numeric{}, fmt.Errorf("pg: invalid binary numeric length %d for %d digits", len(src), ndigits)
+	}
+
+	acc := new(big.Int)
+	for i := 0; i < ndigits; i++ {
+		digit := binary.BigEndian.Uint16(src[8+2*i:])
+		if digit >= numericBase {
+			return Numeric{}, fmt.Errorf("pg: invalid binary numeric digit %d", digit)
+		}
+		acc.Mul(acc, bigNumericBase)
+		acc.Add(acc, big.NewInt(int64(digit)))
+	}
+
+	// acc holds the digits with the last one at position weight-ndigits+1,
+	// shift it so that the result has exactly dscale fractional digits.
+	exp := 4*(weight-ndigits+1) + int(dscale)
+	if exp >= 0 {
+		acc.Mul(acc, pow10(int32(exp)))
+	} else {
+		acc.Quo(acc, pow10(int32(-exp)))
+	}
+
+	if sign == numericNeg {
+		acc.Neg(acc)
+	}
+
+	return Numeric{Int: acc, Scale: dscale}, nil
+}
+
+// AppendBinary appends n in the binary NUMERIC wire format.
+func (n Numeric) AppendBinary(b []byte) ([]byte, error) {
+	switch {
+	case n.NaN:
+		return appendNumericHeader(b, 0, 0, numericNaN, 0), nil
+	case n.Inf > 0:
+		return appendNumericHeader(b, 0, 0, numericPInf, 0), nil
+	case n.Inf < 0:
+		return appendNumericHeader(b, 0, 0, numericNInf, 0), nil
+	}
+
+	n = n.normalized()
+	if n.Scale > 0x3FFF {
+		return nil, fmt.Errorf("pg: numeric scale %d is out of range", n.Scale)
+	}
+
+	sign := uint16(numericPos)
+	if n.Int.Sign() < 0 {
+		sign = numericNeg
+	}
+
+	// Pad the fractional part to a multiple of 4 decimal digits so that the
+	// base-10000 groups line up with the decimal point.
+	fracGroups := (int(n.Scale) + 3) / 4
+	abs := new(big.Int).Abs(n.Int)
+	abs.Mul(abs, pow10(int32(fracGroups*4)-n.Scale))
+
+	var digits []uint16
+	rem := new(big.Int)
+	for abs.Sign() > 0 {
+		abs.QuoRem(abs, bigNumericBase, rem)
+		digits = append(digits, uint16(rem.Int64()))
+	}
+
+	weight := len(digits) - fracGroups - 1
+
+	// digits is least significant first; trailing zero groups are implied.
+	for len(digits) > 0 && digits[0] == 0 {
+		digits = digits[1:]
+	}
+	if len(digits) == 0 {
+		weight = 0
+		sign = numericPos
+	}
+
+	if len(digits) > 0x7FFF || weight > 0x7FFF || weight < -0x8000 {
+		return nil, fmt.Errorf("pg: numeric value is out of range")
+	}
+
+	b = appendNumericHeader(b, len(digits), weight, sign, uint16(n.Scale))
+	for i := len(digits) - 1; i >= 0; i-- {
+		b = binary.BigEndian.AppendUint16(b, digits[i])
+	}
+	return b, nil
+}
+
+func appendNumericHeader(b []byte, ndigits, weight int, sign, dscale uint16) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(int16(ndigits)))
+	b = binary.BigEndian.AppendUint16(b, uint16(int16(weight)))
+	b = binary.BigEndian.AppendUint16(b, sign)
+	b = binary.BigEndian.AppendUint16(b, dscale)
+	return b
+}
+
+func (n Numeric) AppendValue(b []byte, flags int) ([]byte, error) {
+	if n.IsFinite() {
+		return append(b, n.String()...), nil
+	}
+	if hasFlag(flags, quoteFlag) && !hasFlag(flags, arrayFlag) {
+		b = append(b, '\'')
+		b = append(b, n.String()...)
+		return append(b, '\''), nil
+	}
+	return append(b, n.String()...), nil
+}
+
+func (n *Numeric) ScanValue(src []byte, format int16) error {
+	if src == nil {
+		return fmt.Errorf("pg: can't scan NULL into Numeric")
+	}
+
+	var (
+		v   Numeric
+		err error
+	)
+	if format == BinaryFormat {
+		v, err = DecodeNumericBinary(src)
+	} else {
+		v, err = ParseNumeric(string(src))
+	}
+	if err != nil {
+		return err
+	}
+
+	*n = v
+	return nil
+}
+
+// IsFinite reports whether n is neither NaN nor infinite.
+func (n Numeric) IsFinite() bool {
+	return !n.NaN && n.Inf == 0
+}
+
+// String returns the decimal representation of n, keeping its scale.
+func (n Numeric) String() string {
+	switch {
+	case n.NaN:
+		return "NaN"
+	case n.Inf > 0:
+		return "Infinity"
+	case n.Inf < 0:
+		return "-Infinity"
+	}
+
+	n = n.normalized()
+	digits := new(big.Int).Abs(n.Int).String()
+	if n.Scale == 0 {
+		if n.Int.Sign() < 0 {
+			return "-" + digits
+		}
+		return digits
+	}
+
+	scale := int(n.Scale)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+
+	var sb strings.Builder
+	if n.Int.Sign() < 0 {
+		sb.WriteByte('-')
+	}
+	sb.WriteString(digits[:len(digits)-scale])
+	sb.WriteByte('.')
+	sb.WriteString(digits[len(digits)-scale:])
+	return sb.String()
+}
+
+// Rat returns n as an exact rational number.
+func (n Numeric) Rat() (*big.Rat, error) {
+	if !n.IsFinite() {
+		return nil, fmt.Errorf("pg: can't convert %s to big.Rat", n.String())
+	}
+	n = n.normalized()
+	return new(big.Rat).SetFrac(n.Int, pow10(n.Scale)), nil
+}
+
+// BigInt returns n as an integer. It fails if n has a non-zero fractional part.
+func (n Numeric) BigInt() (*big.Int, error) {
+	if !n.IsFinite() {
+		return nil, fmt.Errorf("pg: can't convert %s to big.Int", n.String())
+	}
+	n = n.normalized()
+	quo, rem := new(big.Int).QuoRem(n.Int, pow10(n.Scale), new(big.Int))
+	if rem.Sign() != 0 {
+		return nil, fmt.Errorf("pg: can't convert %s to big.Int without losing precision", n.String())
+	}
+	return quo, nil
+}
+
+// normalized returns a copy of n with a non-nil Int and a non-negative Scale.
+func (n Numeric) normalized() Numeric {
+	if n.Int == nil {
+		return Numeric{Int: new(big.Int), Scale: max(n.Scale, 0)}
+	}
+	if n.Scale < 0 {
+		return Numeric{Int: new(big.Int).Mul(n.Int, pow10(-n.Scale))}
+	}
+	return n
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
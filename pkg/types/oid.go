@@ -0,0 +1,40 @@
+package types
+
+// OIDs of the built-in types, from pg_type.dat.
+const (
+	BoolOID        uint32 = 16
+	ByteaOID       uint32 = 17
+	NameOID        uint32 = 19
+	Int8OID        uint32 = 20
+	Int2OID        uint32 = 21
+	Int4OID        uint32 = 23
+	TextOID        uint32 = 25
+	OIDOID         uint32 = 26
+	Float4OID      uint32 = 700
+	Float8OID      uint32 = 701
+	VarcharOID     uint32 = 1043
+	BpcharOID      uint32 = 1042
+	DateOID        uint32 = 1082
+	TimeOID        uint32 = 1083
+	TimestampOID   uint32 = 1114
+	TimestamptzOID uint32 = 1184
+	IntervalOID    uint32 = 1186
+	TimetzOID      uint32 = 1266
+	BitOID         uint32 = 1560
+	VarbitOID      uint32 = 1562
+	NumericOID     uint32 = 1700
+	UUIDOID        uint32 = 2950
+	JSONOID        uint32 = 114
+	JSONBOID       uint32 = 3802
+
+	BoolArrayOID        uint32 = 1000
+	ByteaArrayOID       uint32 = 1001
+	Int2ArrayOID        uint32 = 1005
+	Int4ArrayOID        uint32 = 1007
+	TextArrayOID        uint32 = 1009
+	Int8ArrayOID        uint32 = 1016
+	Float4ArrayOID      uint32 = 1021
+	Float8ArrayOID      uint32 = 1022
+	TimestamptzArrayOID uint32 = 1185
+	NumericArrayOID     uint32 = 1231
+)
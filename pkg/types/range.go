@@ -0,0 +1,484 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// OIDs of the built-in range and multirange types.
+const (
+	Int4RangeOID      uint32 = 3904
+	NumRangeOID       uint32 = 3906
+	TsRangeOID        uint32 = 3908
+	TstzRangeOID      uint32 = 3910
+	DateRangeOID      uint32 = 3912
+	Int8RangeOID      uint32 = 3926
+	Int4MultirangeOID uint32 = 4451
+	NumMultirangeOID  uint32 = 4532
+	TsMultirangeOID   uint32 = 4533
+	TstzMultirangeOID uint32 = 4534
+	DateMultirangeOID uint32 = 4535
+	Int8MultirangeOID uint32 = 4536
+)
+
+// Flags of the binary range format, from rangetypes.h.
+const (
+	rangeEmpty  = 0x01
+	rangeLBInc  = 0x02
+	rangeUBInc  = 0x04
+	rangeLBInf  = 0x08
+	rangeUBInf  = 0x10
+	rangeLBNull = 0x20
+	rangeUBNull = 0x40
+)
+
+// Range is a PostgreSQL range value. Lower and Upper are ignored when the
+// matching bound is infinite or the range is empty.
+//
+// T can be int32 (int4range), int64 (int8range), Numeric (numrange) or
+// time.Time (tsrange, tstzrange and daterange), or any type that implements
+// ValueAppender, ValueScanner and BinaryAppender.
+type Range[T any] struct {
+	Lower          T
+	Upper          T
+	LowerInclusive bool
+	UpperInclusive bool
+	LowerInfinite  bool
+	UpperInfinite  bool
+	Empty          bool
+}
+
+// Multirange is a PostgreSQL 14+ multirange value.
+type Multirange[T any] []Range[T]
+
+var (
+	_ ValueAppender  = (*Range[int64])(nil)
+	_ ValueScanner   = (*Range[int64])(nil)
+	_ BinaryAppender = (*Range[int64])(nil)
+	_ ValueAppender  = (*Multirange[int64])(nil)
+	_ ValueScanner   = (*Multirange[int64])(nil)
+	_ BinaryAppender = (*Multirange[int64])(nil)
+)
+
+func (r Range[T]) binaryOID() uint32 {
+	switch any(r.Lower).(type) {
+	case int32:
+		return Int4RangeOID
+	case int64:
+		return Int8RangeOID
+	case Numeric:
+		return NumRangeOID
+	}
+	// time.Time ranges can be tsrange, tstzrange or daterange, they are sent
+	// in text format for the server to infer which.
+	return 0
+}
+
+func (m Multirange[T]) binaryOID() uint32 {
+	switch (Range[T]{}).binaryOID() {
+	case Int4RangeOID:
+		return Int4MultirangeOID
+	case Int8RangeOID:
+		return Int8MultirangeOID
+	case NumRangeOID:
+		return NumMultirangeOID
+	}
+	return 0
+}
+
+// NewRange returns the range [lower,upper).
+func NewRange[T any](lower, upper T) Range[T] {
+	return Range[T]{Lower: lower, Upper: upper, LowerInclusive: true}
+}
+
+func (r Range[T]) AppendValue(b []byte, flags int) ([]byte, error) {
+	quote := hasFlag(flags, quoteFlag) && !hasFlag(flags, arrayFlag)
+	if quote {
+		b = append(b, '\'')
+	}
+
+	b, err := r.appendText(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if quote {
+		b = append(b, '\'')
+	}
+	return b, nil
+}
+
+func (r Range[T]) appendText(b []byte) ([]byte, error) {
+	if r.Empty {
+		return append(b, "empty"...), nil
+	}
+
+	if r.LowerInclusive && !r.LowerInfinite {
+		b = append(b, '[')
+	} else {
+		b = append(b, '(')
+	}
+
+	if !r.LowerInfinite {
+		b = appendRangeBound(b, Append(nil, r.Lower, 0))
+	}
+	b = append(b, ',')
+	if !r.UpperInfinite {
+		b = appendRangeBound(b, Append(nil, r.Upper, 0))
+	}
+
+	if r.UpperInclusive && !r.UpperInfinite {
+		b = append(b, ']')
+	} else {
+		b = append(b, ')')
+	}
+	return b, nil
+}
+
+// appendRangeBound quotes a bound when it contains characters that are
+// significant in the range text format.
+func appendRangeBound(b []byte, bound []byte) []byte {
+	if len(bound) > 0 && !strings.ContainsAny(string(bound), "\"\\,()[] \t\n\r") {
+		return append(b, bound...)
+	}
+
+	b = append(b, '"')
+	for _, c := range bound {
+		if c == '"' || c == '\\' {
+			b = append(b, '\\')
+		}
+		b = append(b, c)
+	}
+	return append(b, '"')
+}
+
+// AppendBinary appends r in the binary range format: a flags byte followed by
+// the length-prefixed bounds that are present. time.Time bounds are written
+// as timestamps, so daterange values must be sent in text format.
+func (r Range[T]) AppendBinary(b []byte) ([]byte, error) {
+	if r.Empty {
+		return append(b, rangeEmpty), nil
+	}
+
+	var flags byte
+	if r.LowerInfinite {
+		flags |= rangeLBInf
+	} else if r.LowerInclusive {
+		flags |= rangeLBInc
+	}
+	if r.UpperInfinite {
+		flags |= rangeUBInf
+	} else if r.UpperInclusive {
+		flags |= rangeUBInc
+	}
+	b = append(b, flags)
+
+	var err error
+	if !r.LowerInfinite {
+		if b, err = appendRangeBoundBinary(b, r.Lower); err != nil {
+			return nil, err
+		}
+	}
+	if !r.UpperInfinite {
+		if b, err = appendRangeBoundBinary(b, r.Upper); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func appendRangeBoundBinary(b []byte, v interface{}) ([]byte, error) {
+	start := len(b)
+	b = append(b, 0, 0, 0, 0)
+	b, err := appendBinary(b, v)
+	if err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint32(b[start:], uint32(len(b)-start-4))
+	return b, nil
+}
+
+func (r *Range[T]) ScanValue(src []byte, format int16) error {
+	if src == nil {
+		return fmt.Errorf("pg: can't scan NULL into %T", r)
+	}
+
+	var v Range[T]
+	var err error
+	if format == BinaryFormat {
+		v, err = decodeRangeBinary[T](src)
+	} else {
+		v, err = parseRange[T](string(src))
+	}
+	if err != nil {
+		return err
+	}
+
+	*r = v
+	return nil
+}
+
+func decodeRangeBinary[T any](src []byte) (Range[T], error) {
+	var r Range[T]
+	if len(src) < 1 {
+		return r, fmt.Errorf("pg: invalid binary range length %d", len(src))
+	}
+
+	flags := src[0]
+	if flags&rangeEmpty != 0 {
+		r.Empty = true
+		return r, nil
+	}
+
+	r.LowerInclusive = flags&rangeLBInc != 0
+	r.UpperInclusive = flags&rangeUBInc != 0
+	r.LowerInfinite = flags&(rangeLBInf|rangeLBNull) != 0
+	r.UpperInfinite = flags&(rangeUBInf|rangeUBNull) != 0
+
+	rest := src[1:]
+	var err error
+	if !r.LowerInfinite {
+		if rest, err = scanRangeBoundBinary(&r.Lower, rest); err != nil {
+			return r, err
+		}
+	}
+	if !r.UpperInfinite {
+		if rest, err = scanRangeBoundBinary(&r.Upper, rest); err != nil {
+			return r, err
+		}
+	}
+	if len(rest) != 0 {
+		return r, fmt.Errorf("pg: %d trailing bytes in binary range", len(rest))
+	}
+	return r, nil
+}
+
+func scanRangeBoundBinary(dst interface{}, src []byte) ([]byte, error) {
+	if len(src) < 4 {
+		return nil, fmt.Errorf("pg: truncated binary range bound")
+	}
+	n := int(int32(binary.BigEndian.Uint32(src)))
+	if n < 0 || len(src) < 4+n {
+		return nil, fmt.Errorf("pg: truncated binary range bound")
+	}
+	if err := Scan(dst, src[4:4+n], BinaryFormat); err != nil {
+		return nil, err
+	}
+	return src[4+n:], nil
+}
+
+func parseRange[T any](s string) (Range[T], error) {
+	var r Range[T]
+
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "empty") {
+		r.Empty = true
+		return r, nil
+	}
+
+	if len(s) < 3 || (s[0] != '[' && s[0] != '(') || (s[len(s)-1] != ']' && s[len(s)-1] != ')') {
+		return r, fmt.Errorf("pg: invalid range %q", s)
+	}
+	r.LowerInclusive = s[0] == '['
+	r.UpperInclusive = s[len(s)-1] == ']'
+
+	lower, rest, ok := readRangeBound(s[1:len(s)-1], ',')
+	if !ok || rest == "" || rest[0] != ',' {
+		return r, fmt.Errorf("pg: invalid range %q", s)
+	}
+	upper, rest, ok := readRangeBound(rest[1:], 0)
+	if !ok || rest != "" {
+		return r, fmt.Errorf("pg: invalid range %q", s)
+	}
+
+	if lower == nil {
+		r.LowerInfinite = true
+		r.LowerInclusive = false
+	} else if err := Scan(&r.Lower, []byte(*lower), TextFormat); err != nil {
+		return r, err
+	}
+
+	if upper == nil {
+		r.UpperInfinite = true
+		r.UpperInclusive = false
+	} else if err := Scan(&r.Upper, []byte(*upper), TextFormat); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// readRangeBound reads one bound up to the delim byte (0 reads to the end).
+// A missing bound is returned as nil.
+func readRangeBound(s string, delim byte) (*string, string, bool) {
+	var sb strings.Builder
+	quoted, present := false, false
+
+	i := 0
+loop:
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, "", false
+			}
+			i++
+			sb.WriteByte(s[i])
+			present = true
+		case c == '"':
+			if quoted && i+1 < len(s) && s[i+1] == '"' {
+				sb.WriteByte('"')
+				i++
+			} else {
+				quoted = !quoted
+			}
+			present = true
+		case !quoted && delim != 0 && c == delim:
+			break loop
+		default:
+			sb.WriteByte(c)
+			present = true
+		}
+	}
+	if quoted {
+		return nil, "", false
+	}
+
+	if !present {
+		return nil, s[i:], true
+	}
+	bound := sb.String()
+	return &bound, s[i:], true
+}
+
+func (m Multirange[T]) AppendValue(b []byte, flags int) ([]byte, error) {
+	quote := hasFlag(flags, quoteFlag) && !hasFlag(flags, arrayFlag)
+	if quote {
+		b = append(b, '\'')
+	}
+
+	b = append(b, '{')
+	for i, r := range m {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		var err error
+		if b, err = r.appendText(b); err != nil {
+			return nil, err
+		}
+	}
+	b = append(b, '}')
+
+	if quote {
+		b = append(b, '\'')
+	}
+	return b, nil
+}
+
+// AppendBinary appends m in the binary multirange format: the number of
+// ranges followed by each length-prefixed range.
+func (m Multirange[T]) AppendBinary(b []byte) ([]byte, error) {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(m)))
+	for _, r := range m {
+		start := len(b)
+		b = append(b, 0, 0, 0, 0)
+		var err error
+		if b, err = r.AppendBinary(b); err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint32(b[start:], uint32(len(b)-start-4))
+	}
+	return b, nil
+}
+
+func (m *Multirange[T]) ScanValue(src []byte, format int16) error {
+	if src == nil {
+		return fmt.Errorf("pg: can't scan NULL into %T", m)
+	}
+
+	var v Multirange[T]
+	var err error
+	if format == BinaryFormat {
+		v, err = decodeMultirangeBinary[T](src)
+	} else {
+		v, err = parseMultirange[T](string(src))
+	}
+	if err != nil {
+		return err
+	}
+
+	*m = v
+	return nil
+}
+
+func decodeMultirangeBinary[T any](src []byte) (Multirange[T], error) {
+	if len(src) < 4 {
+		return nil, fmt.Errorf("pg: invalid binary multirange length %d", len(src))
+	}
+	count := int(binary.BigEndian.Uint32(src))
+	src = src[4:]
+
+	m := make(Multirange[T], 0, count)
+	for i := 0; i < count; i++ {
+		if len(src) < 4 {
+			return nil, fmt.Errorf("pg: truncated binary multirange")
+		}
+		n := int(int32(binary.BigEndian.Uint32(src)))
+		if n < 0 || len(src) < 4+n {
+			return nil, fmt.Errorf("pg: truncated binary multirange")
+		}
+		r, err := decodeRangeBinary[T](src[4 : 4+n])
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, r)
+		src = src[4+n:]
+	}
+	return m, nil
+}
+
+func parseMultirange[T any](s string) (Multirange[T], error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("pg: invalid multirange %q", s)
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+
+	m := Multirange[T]{}
+	for s != "" {
+		end := rangeLiteralEnd(s)
+		if end == -1 {
+			return nil, fmt.Errorf("pg: invalid multirange %q", s)
+		}
+		r, err := parseRange[T](s[:end])
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, r)
+
+		s = strings.TrimSpace(s[end:])
+		s = strings.TrimSpace(strings.TrimPrefix(s, ","))
+	}
+	return m, nil
+}
+
+// rangeLiteralEnd returns the index just past the first range literal in s.
+func rangeLiteralEnd(s string) int {
+	if strings.HasPrefix(strings.ToLower(s), "empty") {
+		return len("empty")
+	}
+	quoted := false
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case !quoted && (c == ']' || c == ')'):
+			return i + 1
+		}
+	}
+	return -1
+}
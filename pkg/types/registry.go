@@ -0,0 +1,224 @@
+package types
+
+import (
+	"fmt"
+	"sync"
+)
+
+// TypeKind is the kind of a type, as in pg_type.typtype.
+type TypeKind byte
+
+const (
+	BaseKind      TypeKind = 'b'
+	CompositeKind TypeKind = 'c'
+	DomainKind    TypeKind = 'd'
+	EnumKind      TypeKind = 'e'
+	RangeKind     TypeKind = 'r'
+	// MultirangeKind marks PostgreSQL 14+ multiranges.
+	MultirangeKind TypeKind = 'm'
+	// ArrayKind marks array types, which are base types in pg_type.
+	ArrayKind TypeKind = 'A'
+)
+
+// Type is a type resolved on a connection, usually one whose OID is not
+// known in advance.
+type Type struct {
+	OID      uint32
+	Name     string
+	Kind     TypeKind
+	ArrayOID uint32
+	// BaseOID is the base type of a domain, the subtype of a range, the
+	// range type of a multirange or the element type of an array.
+	BaseOID uint32
+	// EnumValues are the labels of an enum, in sort order.
+	EnumValues []string
+	// Decode converts a column value of this type, src is never nil.
+	Decode func(src []byte, format int16) (interface{}, error)
+}
+
+// NewEnumType returns an enum whose values decode into strings.
+func NewEnumType(oid uint32, name string, arrayOID uint32, labels []string) *Type {
+	return &Type{
+		OID:        oid,
+		Name:       name,
+		Kind:       EnumKind,
+		ArrayOID:   arrayOID,
+		EnumValues: labels,
+		Decode: func(src []byte, _ int16) (interface{}, error) {
+			return string(src), nil
+		},
+	}
+}
+
+// NewDomainType returns a domain that decodes with the codec of its base
+// type, looked up in r when the value is decoded.
+func NewDomainType(r *Registry, oid uint32, name string, arrayOID, baseOID uint32) *Type {
+	return &Type{
+		OID:      oid,
+		Name:     name,
+		Kind:     DomainKind,
+		ArrayOID: arrayOID,
+		BaseOID:  baseOID,
+		Decode: func(src []byte, format int16) (interface{}, error) {
+			return r.decodeBase(baseOID, src, format)
+		},
+	}
+}
+
+// NewRangeType returns a range over subtype whose bounds are decoded as
+// strings in text format and []byte in binary format.
+func NewRangeType(oid uint32, name string, arrayOID, subtype uint32) *Type {
+	return &Type{
+		OID:      oid,
+		Name:     name,
+		Kind:     RangeKind,
+		ArrayOID: arrayOID,
+		BaseOID:  subtype,
+		Decode: func(src []byte, format int16) (interface{}, error) {
+			var v Range[interface{}]
+			err := v.ScanValue(src, format)
+			return v, err
+		},
+	}
+}
+
+// NewMultirangeType returns a multirange of rangeOID that decodes like the
+// ranges of NewRangeType.
+func NewMultirangeType(oid uint32, name string, arrayOID, rangeOID uint32) *Type {
+	return &Type{
+		OID:      oid,
+		Name:     name,
+		Kind:     MultirangeKind,
+		ArrayOID: arrayOID,
+		BaseOID:  rangeOID,
+		Decode: func(src []byte, format int16) (interface{}, error) {
+			var v Multirange[interface{}]
+			err := v.ScanValue(src, format)
+			return v, err
+		},
+	}
+}
+
+// NewArrayType returns an array of elemOID that decodes into []interface{},
+// with elements decoded by the codec of elemOID looked up in r.
+func NewArrayType(r *Registry, oid uint32, name string, elemOID uint32) *Type {
+	return &Type{
+		OID:     oid,
+		Name:    name,
+		Kind:    ArrayKind,
+		BaseOID: elemOID,
+		Decode: func(src []byte, format int16) (interface{}, error) {
+			return ParseArray(src, format, func(elem []byte, format int16) (interface{}, error) {
+				return r.decodeBase(elemOID, elem, format)
+			})
+		},
+	}
+}
+
+// ValidateEnum reports an error when value is not a label of the enum t.
+func (t *Type) ValidateEnum(value string) error {
+	if t.Kind != EnumKind {
+		return fmt.Errorf("pg: type %s is not an enum", t.Name)
+	}
+	for _, label := range t.EnumValues {
+		if label == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("pg: invalid input value for enum %s: %q", t.Name, value)
+}
+
+// Registry maps type OIDs and names to the types resolved on a connection.
+// It is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	byOID  map[uint32]*Type
+	byName map[string]*Type
+}
+
+// NewRegistry returns a registry of the built-in types decoded in query
+// results, see builtinTypes.
+func NewRegistry() *Registry {
+	r := &Registry{
+		byOID:  make(map[uint32]*Type),
+		byName: make(map[string]*Type),
+	}
+	for _, oid := range builtinTypes {
+		r.Register(newBuiltinType(oid))
+	}
+	return r
+}
+
+// builtinTypes are the built-in types whose columns are decoded with
+// DecodeValue rather than returned as strings.
+var builtinTypes = []uint32{
+	PointOID, LineOID, LsegOID, BoxOID, PathOID, PolygonOID, CircleOID,
+	InetOID, CidrOID, MacaddrOID, Macaddr8OID,
+}
+
+func newBuiltinType(oid uint32) *Type {
+	return &Type{
+		OID:  oid,
+		Name: builtinTypeNames[oid],
+		Kind: BaseKind,
+		Decode: func(src []byte, format int16) (interface{}, error) {
+			return DecodeValue(oid, src, format)
+		},
+	}
+}
+
+// Register adds t to the registry, replacing any type with the same OID or name.
+func (r *Registry) Register(t *Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if old, ok := r.byOID[t.OID]; ok {
+		delete(r.byName, old.Name)
+	}
+	r.byOID[t.OID] = t
+	r.byName[t.Name] = t
+}
+
+func (r *Registry) TypeByOID(oid uint32) (*Type, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.byOID[oid]
+	return t, ok
+}
+
+func (r *Registry) TypeByName(name string) (*Type, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.byName[name]
+	return t, ok
+}
+
+// Decode decodes src with the type registered for oid. ok is false when no
+// type is registered for oid.
+func (r *Registry) Decode(oid uint32, src []byte, format int16) (v interface{}, ok bool, err error) {
+	t, ok := r.TypeByOID(oid)
+	if !ok || t.Decode == nil {
+		return nil, false, nil
+	}
+	if src == nil {
+		return nil, true, nil
+	}
+	v, err = t.Decode(src, format)
+	return v, true, err
+}
+
+// decodeBase decodes src with the type registered for oid, falling back to
+// the built-in decoding in binary format and to a string in text format,
+// like columns of built-in types.
+func (r *Registry) decodeBase(oid uint32, src []byte, format int16) (interface{}, error) {
+	v, ok, err := r.Decode(oid, src, format)
+	if ok {
+		return v, err
+	}
+	if format == BinaryFormat {
+		return DecodeValue(oid, src, format)
+	}
+	return string(src), nil
+}
@@ -0,0 +1,285 @@
+package types
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Scan decodes a column value into dst, which must be a pointer.
+// src is nil for NULL and format is TextFormat or BinaryFormat.
+// Pointer-to-pointer destinations are set to nil for NULL.
+func Scan(dst interface{}, src []byte, format int16) error {
+	if s, ok := dst.(ValueScanner); ok {
+		return s.ScanValue(src, format)
+	}
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("pg: Scan(non-pointer %T)", dst)
+	}
+	return scanValue(v.Elem(), src, format)
+}
+
+func scanValue(v reflect.Value, src []byte, format int16) error {
+	if v.CanAddr() {
+		if s, ok := v.Addr().Interface().(ValueScanner); ok {
+			return s.ScanValue(src, format)
+		}
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if src == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return Scan(v.Interface(), src, format)
+	}
+
+	if v.Type() == hstoreMapType {
+		var h Hstore
+		if err := h.ScanValue(src, format); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(map[string]*string(h)))
+		return nil
+	}
+
+	if v.Kind() == reflect.Interface {
+		if src == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else if format == BinaryFormat {
+			v.Set(reflect.ValueOf(append([]byte(nil), src...)))
+		} else {
+			v.Set(reflect.ValueOf(string(src)))
+		}
+		return nil
+	}
+
+	if src == nil {
+		return fmt.Errorf("pg: can't scan NULL into %s", v.Type())
+	}
+
+	if ok, err := scanNetValue(v, src, format); ok {
+		return err
+	}
+
+	if v.Type() == timeType {
+		tm, err := decodeTime(src, format)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(string(src))
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			break
+		}
+		b, err := decodeBytes(src, format)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(b)
+		return nil
+	case reflect.Bool:
+		b, err := decodeBool(src, format)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := decodeInt(src, format)
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("pg: value %d overflows %s", n, v.Type())
+		}
+		v.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := decodeInt(src, format)
+		if err != nil {
+			return err
+		}
+		if n < 0 || v.OverflowUint(uint64(n)) {
+			return fmt.Errorf("pg: value %d overflows %s", n, v.Type())
+		}
+		v.SetUint(uint64(n))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := decodeFloat(src, format)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+		return nil
+	}
+
+	return fmt.Errorf("pg: can't scan into %s", v.Type())
+}
+
+func decodeTime(src []byte, format int16) (time.Time, error) {
+	if format == BinaryFormat {
+		return DecodeTimeBinary(src)
+	}
+	return ParseTime(string(src))
+}
+
+func decodeBytes(src []byte, format int16) ([]byte, error) {
+	if format == BinaryFormat {
+		return append([]byte(nil), src...), nil
+	}
+	return ParseBytea(src)
+}
+
+// ParseBytea decodes the text output of a bytea column in hex or escape format.
+func ParseBytea(src []byte) ([]byte, error) {
+	if len(src) >= 2 && src[0] == '\\' && src[1] == 'x' {
+		b := make([]byte, hex.DecodedLen(len(src)-2))
+		if _, err := hex.Decode(b, src[2:]); err != nil {
+			return nil, fmt.Errorf("pg: invalid bytea: %w", err)
+		}
+		return b, nil
+	}
+
+	b := make([]byte, 0, len(src))
+	for i := 0; i < len(src); i++ {
+		if src[i] != '\\' {
+			b = append(b, src[i])
+			continue
+		}
+		if i+1 < len(src) && src[i+1] == '\\' {
+			b = append(b, '\\')
+			i++
+			continue
+		}
+		if i+3 >= len(src) {
+			return nil, fmt.Errorf("pg: invalid bytea %q", src)
+		}
+		n, err := strconv.ParseUint(string(src[i+1:i+4]), 8, 8)
+		if err != nil {
+			return nil, fmt.Errorf("pg: invalid bytea %q", src)
+		}
+		b = append(b, byte(n))
+		i += 3
+	}
+	return b, nil
+}
+
+func decodeBool(src []byte, format int16) (bool, error) {
+	if format == BinaryFormat {
+		if len(src) != 1 {
+			return false, fmt.Errorf("pg: invalid binary bool length %d", len(src))
+		}
+		return src[0] != 0, nil
+	}
+	switch string(src) {
+	case "t", "true":
+		return true, nil
+	case "f", "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("pg: invalid bool %q", src)
+}
+
+func decodeInt(src []byte, format int16) (int64, error) {
+	if format == BinaryFormat {
+		switch len(src) {
+		case 2:
+			return int64(int16(binary.BigEndian.Uint16(src))), nil
+		case 4:
+			return int64(int32(binary.BigEndian.Uint32(src))), nil
+		case 8:
+			return int64(binary.BigEndian.Uint64(src)), nil
+		}
+		return 0, fmt.Errorf("pg: invalid binary integer length %d", len(src))
+	}
+	return strconv.ParseInt(string(src), 10, 64)
+}
+
+func decodeFloat(src []byte, format int16) (float64, error) {
+	if format == BinaryFormat {
+		switch len(src) {
+		case 4:
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(src))), nil
+		case 8:
+			return math.Float64frombits(binary.BigEndian.Uint64(src)), nil
+		}
+		return 0, fmt.Errorf("pg: invalid binary float length %d", len(src))
+	}
+	return strconv.ParseFloat(string(src), 64)
+}
+
+// DecodeValue decodes a column value of a built-in type into its natural Go
+// type. Values of unknown types are returned as string in text format and as
+// []byte in binary format.
+func DecodeValue(oid uint32, src []byte, format int16) (interface{}, error) {
+	if src == nil {
+		return nil, nil
+	}
+
+	switch oid {
+	case BoolOID:
+		return decodeBool(src, format)
+	case Int2OID:
+		n, err := decodeInt(src, format)
+		return int16(n), err
+	case Int4OID:
+		n, err := decodeInt(src, format)
+		return int32(n), err
+	case Int8OID:
+		return decodeInt(src, format)
+	case OIDOID:
+		if format == BinaryFormat && len(src) == 4 {
+			return binary.BigEndian.Uint32(src), nil
+		}
+		n, err := strconv.ParseUint(string(src), 10, 32)
+		return uint32(n), err
+	case Float4OID:
+		f, err := decodeFloat(src, format)
+		return float32(f), err
+	case Float8OID:
+		return decodeFloat(src, format)
+	case NumericOID:
+		var n Numeric
+		err := n.ScanValue(src, format)
+		return n, err
+	case ByteaOID:
+		return decodeBytes(src, format)
+	case DateOID, TimestampOID, TimestamptzOID:
+		return decodeTime(src, format)
+	case InetOID, CidrOID:
+		return ParseInet(src, format)
+	case MacaddrOID, Macaddr8OID:
+		return ParseMacaddr(src, format)
+	case PointOID, LineOID, LsegOID, BoxOID, PathOID, PolygonOID, CircleOID:
+		return decodeGeometricValue(oid, src, format)
+	case TextOID, VarcharOID, BpcharOID, NameOID, JSONOID:
+		return string(src), nil
+	case JSONBOID:
+		if format == BinaryFormat && len(src) > 0 {
+			return string(src[1:]), nil // skip the jsonb version byte
+		}
+		return string(src), nil
+	}
+
+	if format == BinaryFormat {
+		return append([]byte(nil), src...), nil
+	}
+	return string(src), nil
+}
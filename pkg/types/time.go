@@ -0,0 +1,83 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	timestamptzFormat = "2006-01-02 15:04:05.999999Z07:00"
+	dateFormat        = "2006-01-02"
+
+	secondsPerDay = 24 * 60 * 60
+)
+
+// pgEpoch is the origin of the binary date and timestamp formats.
+var pgEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+var timeParseFormats = []string{
+	"2006-01-02 15:04:05Z07:00:00",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05Z07",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+	dateFormat,
+}
+
+func AppendTime(b []byte, tm time.Time, flags int) []byte {
+	if hasFlag(flags, quoteFlag) {
+		b = append(b, '\'')
+	}
+	b = tm.AppendFormat(b, timestamptzFormat)
+	if hasFlag(flags, quoteFlag) {
+		b = append(b, '\'')
+	}
+	return b
+}
+
+// ParseTime parses the text output of date, timestamp and timestamptz columns.
+func ParseTime(s string) (time.Time, error) {
+	bc := strings.HasSuffix(s, " BC")
+	s = strings.TrimSuffix(s, " BC")
+
+	for _, format := range timeParseFormats {
+		tm, err := time.Parse(format, s)
+		if err != nil {
+			continue
+		}
+		if bc {
+			tm = tm.AddDate(1-2*tm.Year(), 0, 0)
+		}
+		return tm, nil
+	}
+	return time.Time{}, fmt.Errorf("pg: can't parse time %q", s)
+}
+
+// DecodeTimeBinary decodes a binary date (4 bytes, days since 2000-01-01) or
+// timestamp/timestamptz (8 bytes, microseconds since 2000-01-01 UTC).
+func DecodeTimeBinary(src []byte) (time.Time, error) {
+	switch len(src) {
+	case 4:
+		days := int64(int32(binary.BigEndian.Uint32(src)))
+		return time.Unix(pgEpoch.Unix()+days*secondsPerDay, 0).UTC(), nil
+	case 8:
+		micros := int64(binary.BigEndian.Uint64(src))
+		return time.UnixMicro(pgEpoch.UnixMicro() + micros).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("pg: invalid binary time length %d", len(src))
+}
+
+// AppendTimestampBinary appends tm in the binary timestamptz format.
+func AppendTimestampBinary(b []byte, tm time.Time) []byte {
+	micros := tm.UnixMicro() - pgEpoch.UnixMicro()
+	return binary.BigEndian.AppendUint64(b, uint64(micros))
+}
+
+// AppendDateBinary appends the date of tm in the binary date format.
+func AppendDateBinary(b []byte, tm time.Time) []byte {
+	date := time.Date(tm.Year(), tm.Month(), tm.Day(), 0, 0, 0, 0, time.UTC)
+	days := int32((date.Unix() - pgEpoch.Unix()) / secondsPerDay)
+	return binary.BigEndian.AppendUint32(b, uint32(days))
+}
@@ -0,0 +1,230 @@
+package types
+
+import "strconv"
+
+// builtinTypeNames are the pg_type names of the built-in types.
+var builtinTypeNames = map[uint32]string{
+	BoolOID:           "bool",
+	ByteaOID:          "bytea",
+	18:                "char",
+	NameOID:           "name",
+	Int8OID:           "int8",
+	Int2OID:           "int2",
+	Int4OID:           "int4",
+	TextOID:           "text",
+	OIDOID:            "oid",
+	JSONOID:           "json",
+	142:               "xml",
+	PointOID:          "point",
+	LsegOID:           "lseg",
+	PathOID:           "path",
+	BoxOID:            "box",
+	PolygonOID:        "polygon",
+	LineOID:           "line",
+	CircleOID:         "circle",
+	Float4OID:         "float4",
+	Float8OID:         "float8",
+	790:               "money",
+	MacaddrOID:        "macaddr",
+	Macaddr8OID:       "macaddr8",
+	InetOID:           "inet",
+	CidrOID:           "cidr",
+	BpcharOID:         "bpchar",
+	VarcharOID:        "varchar",
+	DateOID:           "date",
+	TimeOID:           "time",
+	TimestampOID:      "timestamp",
+	TimestamptzOID:    "timestamptz",
+	IntervalOID:       "interval",
+	TimetzOID:         "timetz",
+	BitOID:            "bit",
+	VarbitOID:         "varbit",
+	NumericOID:        "numeric",
+	RecordOID:         "record",
+	2278:              "void",
+	UUIDOID:           "uuid",
+	JSONBOID:          "jsonb",
+	Int4RangeOID:      "int4range",
+	NumRangeOID:       "numrange",
+	TsRangeOID:        "tsrange",
+	TstzRangeOID:      "tstzrange",
+	DateRangeOID:      "daterange",
+	Int8RangeOID:      "int8range",
+	Int4MultirangeOID: "int4multirange",
+	NumMultirangeOID:  "nummultirange",
+	TsMultirangeOID:   "tsmultirange",
+	TstzMultirangeOID: "tstzmultirange",
+	DateMultirangeOID: "datemultirange",
+	Int8MultirangeOID: "int8multirange",
+}
+
+// builtinArrayElems maps the built-in array types to their element types.
+var builtinArrayElems = map[uint32]uint32{
+	BoolArrayOID:        BoolOID,
+	ByteaArrayOID:       ByteaOID,
+	1002:                18,
+	1003:                NameOID,
+	Int2ArrayOID:        Int2OID,
+	Int4ArrayOID:        Int4OID,
+	TextArrayOID:        TextOID,
+	1028:                OIDOID,
+	1014:                BpcharOID,
+	1015:                VarcharOID,
+	Int8ArrayOID:        Int8OID,
+	Float4ArrayOID:      Float4OID,
+	Float8ArrayOID:      Float8OID,
+	1182:                DateOID,
+	1183:                TimeOID,
+	1115:                TimestampOID,
+	TimestamptzArrayOID: TimestamptzOID,
+	1187:                IntervalOID,
+	1270:                TimetzOID,
+	1561:                BitOID,
+	1563:                VarbitOID,
+	NumericArrayOID:     NumericOID,
+	2951:                UUIDOID,
+	199:                 JSONOID,
+	3807:                JSONBOID,
+	1041:                InetOID,
+	651:                 CidrOID,
+	1040:                MacaddrOID,
+	775:                 Macaddr8OID,
+}
+
+// varHdrSz is the length header PostgreSQL adds to variable-length typmods.
+const varHdrSz = 4
+
+// Interval field masks of the interval typmod, from datetime.h.
+const (
+	intervalMonth  = 1 << 1
+	intervalYear   = 1 << 2
+	intervalDay    = 1 << 3
+	intervalHour   = 1 << 10
+	intervalMinute = 1 << 11
+	intervalSecond = 1 << 12
+
+	intervalFullRange     = 0x7FFF
+	intervalFullPrecision = 0xFFFF
+)
+
+var intervalFieldNames = map[int32]string{
+	intervalYear:                 "year",
+	intervalMonth:                "month",
+	intervalDay:                  "day",
+	intervalHour:                 "hour",
+	intervalMinute:               "minute",
+	intervalSecond:               "second",
+	intervalYear | intervalMonth: "year to month",
+	intervalDay | intervalHour:   "day to hour",
+	intervalDay | intervalHour | intervalMinute:                  "day to minute",
+	intervalDay | intervalHour | intervalMinute | intervalSecond: "day to second",
+	intervalHour | intervalMinute:                                "hour to minute",
+	intervalHour | intervalMinute | intervalSecond:               "hour to second",
+	intervalMinute | intervalSecond:                              "minute to second",
+}
+
+// TypeName returns the name of the type oid with the modifiers of typmod,
+// like varchar(255), numeric(10,2) or timestamptz(3)[]. Types that are not
+// built in are looked up in r, which may be nil. It returns an empty string
+// for unknown types.
+func (r *Registry) TypeName(oid uint32, typmod int32) string {
+	if elem, ok := r.arrayElem(oid); ok {
+		if name := r.TypeName(elem, typmod); name != "" {
+			return name + "[]"
+		}
+		return ""
+	}
+
+	name, ok := builtinTypeNames[oid]
+	if !ok && r != nil {
+		if t, found := r.TypeByOID(oid); found {
+			name, ok = t.Name, true
+		}
+	}
+	if !ok {
+		return ""
+	}
+
+	switch oid {
+	case IntervalOID:
+		if typmod >= 0 {
+			if fields, ok := intervalFieldNames[(typmod>>16)&intervalFullRange]; ok {
+				name += " " + fields
+			}
+		}
+		if precision, ok := TypmodPrecision(oid, typmod); ok {
+			name += "(" + strconv.Itoa(precision) + ")"
+		}
+	case NumericOID:
+		if precision, scale, ok := TypmodPrecisionScale(oid, typmod); ok {
+			name += "(" + strconv.Itoa(precision) + "," + strconv.Itoa(scale) + ")"
+		}
+	default:
+		if length, ok := TypmodLength(oid, typmod); ok {
+			name += "(" + strconv.Itoa(length) + ")"
+		} else if precision, ok := TypmodPrecision(oid, typmod); ok {
+			name += "(" + strconv.Itoa(precision) + ")"
+		}
+	}
+	return name
+}
+
+func (r *Registry) arrayElem(oid uint32) (uint32, bool) {
+	if elem, ok := builtinArrayElems[oid]; ok {
+		return elem, true
+	}
+	if r != nil {
+		if t, ok := r.TypeByOID(oid); ok && t.Kind == ArrayKind {
+			return t.BaseOID, true
+		}
+	}
+	return 0, false
+}
+
+// TypmodLength returns the declared length of a varchar, bpchar, bit or
+// varbit type, ok is false for other types and when there is no length.
+func TypmodLength(oid uint32, typmod int32) (length int, ok bool) {
+	switch oid {
+	case VarcharOID, BpcharOID:
+		if typmod < varHdrSz {
+			return 0, false
+		}
+		return int(typmod - varHdrSz), true
+	case BitOID, VarbitOID:
+		if typmod < 0 {
+			return 0, false
+		}
+		return int(typmod), true
+	}
+	return 0, false
+}
+
+// TypmodPrecisionScale returns the declared precision and scale of a numeric
+// type, ok is false for other types and when there is no declared precision.
+func TypmodPrecisionScale(oid uint32, typmod int32) (precision, scale int, ok bool) {
+	if oid != NumericOID || typmod < varHdrSz {
+		return 0, 0, false
+	}
+	typmod -= varHdrSz
+	// Since PostgreSQL 15 the scale is an 11-bit signed value.
+	return int((typmod >> 16) & 0xFFFF), int(((typmod & 0x7FF) ^ 1024) - 1024), true
+}
+
+// TypmodPrecision returns the fractional seconds precision of a time, timetz,
+// timestamp, timestamptz or interval type, ok is false for other types and
+// when there is no declared precision.
+func TypmodPrecision(oid uint32, typmod int32) (precision int, ok bool) {
+	if typmod < 0 {
+		return 0, false
+	}
+	switch oid {
+	case TimeOID, TimetzOID, TimestampOID, TimestamptzOID:
+		return int(typmod), true
+	case IntervalOID:
+		if typmod&intervalFullPrecision == intervalFullPrecision {
+			return 0, false
+		}
+		return int(typmod & intervalFullPrecision), true
+	}
+	return 0, false
+}
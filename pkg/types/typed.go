@@ -0,0 +1,232 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// TypedValue is a query parameter with an explicit type, see Typed.
+type TypedValue struct {
+	OID   uint32
+	Value interface{}
+}
+
+// Typed pins the type of a query parameter to oid, for parameters whose type
+// the server can't infer, like in SELECT $1. A nil value is sent as NULL.
+func Typed(oid uint32, value interface{}) TypedValue {
+	return TypedValue{OID: oid, Value: value}
+}
+
+// ParamOID returns the type OID a parameter is declared with in Parse: the
+// OID of a TypedValue, or 0 to let the server infer it from the query.
+// Other values are encoded for the inferred type, see AppendParam.
+func ParamOID(v interface{}) uint32 {
+	if typed, ok := v.(TypedValue); ok {
+		return typed.OID
+	}
+	return 0
+}
+
+// AppendParam appends v encoded for a parameter of type oid, as returned by
+// the server in ParameterDescription, and returns the format it is encoded
+// in. Values without a binary encoding for oid are sent in text format, and
+// values without a text encoding either return an error.
+func AppendParam(b []byte, oid uint32, v interface{}) (data []byte, format int16, err error) {
+	data, ok, err := appendBinaryFor(b, oid, v)
+	if err != nil {
+		return nil, 0, err
+	}
+	if ok {
+		return data, BinaryFormat, nil
+	}
+	if m, ok := v.(map[string]*string); ok {
+		v = Hstore(m)
+	}
+	if appender, ok := v.(ValueAppender); ok {
+		data, err := appender.AppendValue(b, 0)
+		return data, TextFormat, err
+	}
+	if typ := reflect.TypeOf(v); typ != nil && !hasAppender(typ) {
+		return nil, 0, fmt.Errorf("pg: no encoding of %T for type %d", v, oid)
+	}
+	return Append(b, v, 0), TextFormat, nil
+}
+
+// appendBinaryFor appends v in binary format for the type oid, ok is false
+// when there is no such encoding.
+func appendBinaryFor(b []byte, oid uint32, v interface{}) (data []byte, ok bool, err error) {
+	if oid != 0 && BinaryOID(v) == oid {
+		data, err = appendBinary(b, v)
+		return data, err == nil, err
+	}
+	return appendBinaryAs(b, oid, v)
+}
+
+// appendBinaryAs converts v to the type oid and appends it in binary format,
+// ok is false when there is no such conversion.
+func appendBinaryAs(b []byte, oid uint32, v interface{}) (data []byte, ok bool, err error) {
+	switch oid {
+	case Int2OID, Int4OID, Int8OID:
+		n, ok := toInt64(v)
+		if !ok {
+			return b, false, nil
+		}
+		switch oid {
+		case Int2OID:
+			if n < math.MinInt16 || n > math.MaxInt16 {
+				return nil, false, fmt.Errorf("pg: value %d out of range for smallint", n)
+			}
+			return binary.BigEndian.AppendUint16(b, uint16(n)), true, nil
+		case Int4OID:
+			if n < math.MinInt32 || n > math.MaxInt32 {
+				return nil, false, fmt.Errorf("pg: value %d out of range for integer", n)
+			}
+			return binary.BigEndian.AppendUint32(b, uint32(n)), true, nil
+		}
+		return binary.BigEndian.AppendUint64(b, uint64(n)), true, nil
+
+	case Float4OID, Float8OID:
+		f, ok := toFloat64(v)
+		if !ok {
+			return b, false, nil
+		}
+		if oid == Float4OID {
+			return binary.BigEndian.AppendUint32(b, math.Float32bits(float32(f))), true, nil
+		}
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(f)), true, nil
+
+	case NumericOID:
+		n, ok := toInt64(v)
+		if !ok {
+			return b, false, nil
+		}
+		data, err := NumericFromBigInt(big.NewInt(n)).AppendBinary(b)
+		return data, err == nil, err
+
+	case TimestampOID, DateOID:
+		tm, ok := v.(time.Time)
+		if !ok {
+			return b, false, nil
+		}
+		if oid == DateOID {
+			return AppendDateBinary(b, tm), true, nil
+		}
+		// timestamp without time zone keeps the wall clock of tm
+		wall := time.Date(tm.Year(), tm.Month(), tm.Day(), tm.Hour(), tm.Minute(), tm.Second(), tm.Nanosecond(), time.UTC)
+		return AppendTimestampBinary(b, wall), true, nil
+
+	case Int2ArrayOID, Int4ArrayOID, Int8ArrayOID, Float4ArrayOID, Float8ArrayOID, NumericArrayOID, TextArrayOID:
+		return appendArrayBinaryAs(b, oid, v)
+	}
+	return b, false, nil
+}
+
+// arrayElemOIDs are the element types of the arrays appendArrayBinaryAs
+// converts slices to.
+var arrayElemOIDs = map[uint32]uint32{
+	Int2ArrayOID:    Int2OID,
+	Int4ArrayOID:    Int4OID,
+	Int8ArrayOID:    Int8OID,
+	Float4ArrayOID:  Float4OID,
+	Float8ArrayOID:  Float8OID,
+	NumericArrayOID: NumericOID,
+	TextArrayOID:    TextOID,
+}
+
+// appendArrayBinaryAs appends the slice v as a one-dimensional array of type
+// oid, converting each element, see appendArrayBinary. ok is false when v is
+// not a slice or an element can't be converted. Nil elements are NULL.
+func appendArrayBinaryAs(b []byte, oid uint32, v interface{}) (data []byte, ok bool, err error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return b, false, nil
+	}
+	elemOID := arrayElemOIDs[oid]
+
+	start := len(b)
+	ndim := 1
+	if rv.Len() == 0 {
+		ndim = 0
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(ndim))
+	hasNullIdx := len(b)
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = binary.BigEndian.AppendUint32(b, elemOID)
+	if ndim == 0 {
+		return b, true, nil
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(rv.Len()))
+	b = binary.BigEndian.AppendUint32(b, 1) // lower bound
+
+	for i := 0; i < rv.Len(); i++ {
+		elem := rv.Index(i)
+		for (elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface) && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+		if (elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface) && elem.IsNil() {
+			binary.BigEndian.PutUint32(b[hasNullIdx:], 1)
+			b = binary.BigEndian.AppendUint32(b, uint32(nullLength))
+			continue
+		}
+
+		lengthIdx := len(b)
+		b = append(b, 0, 0, 0, 0)
+		if elemOID == TextOID {
+			b, ok = appendTextElem(b, elem)
+		} else {
+			b, ok, err = appendBinaryFor(b, elemOID, elem.Interface())
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("pg: array element %d: %w", i, err)
+		}
+		if !ok {
+			return b[:start], false, nil
+		}
+		binary.BigEndian.PutUint32(b[lengthIdx:], uint32(len(b)-lengthIdx-4))
+	}
+	return b, true, nil
+}
+
+// appendTextElem appends a string or a number as a text array element, the
+// binary format of text being the text itself.
+func appendTextElem(b []byte, elem reflect.Value) ([]byte, bool) {
+	switch elem.Kind() {
+	case reflect.String:
+		return append(b, elem.String()...), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return Append(b, elem.Interface(), 0), true
+	}
+	return b, false
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	if n, ok := toInt64(v); ok {
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -2,10 +2,22 @@
 
 package types
 
+// Format codes used by the protocol for parameters and result columns.
+const (
+	TextFormat   int16 = 0
+	BinaryFormat int16 = 1
+)
+
 type ValueAppender interface {
 	AppendValue(b []byte, flags int) ([]byte, error)
 }
 
+// ValueScanner is implemented by types that can decode themselves from a
+// column value. src is nil for NULL and format is TextFormat or BinaryFormat.
+type ValueScanner interface {
+	ScanValue(src []byte, format int16) error
+}
+
 //------------------------------------------------------------------------------
 
 // Safe represents a safe SQL query.
@@ -0,0 +1,186 @@
+package protocol_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	pbkdf2 "postgres-protocol-go"
+	"postgres-protocol-go/internal/protocol"
+	"postgres-protocol-go/pkg/models"
+	"strings"
+	"testing"
+)
+
+// serveSCRAM authenticates conn with SCRAM, offering mechanisms, and
+// returns the GS2 header sent by the client. The client's channel binding
+// must match cbData when it uses SCRAM-SHA-256-PLUS.
+func serveSCRAM(conn net.Conn, mechanisms []string, password string, cbData []byte) (string, error) {
+	conn.Write(authMessage(10, strings.Join(mechanisms, "\x00")+"\x00\x00"))
+
+	identifier, body, err := readFrontendMessage(conn)
+	if err != nil {
+		return "", err
+	}
+	if identifier != 'p' {
+		return "", fmt.Errorf("expected SASLInitialResponse, got %c", identifier)
+	}
+	mechanism, rest, _ := strings.Cut(string(body), "\x00")
+	clientFirst := rest[4:]
+	i := strings.Index(clientFirst, ",")
+	j := strings.Index(clientFirst[i+1:], ",") + i + 1
+	gs2Header, clientFirstBare := clientFirst[:j+1], clientFirst[j+1:]
+	// the escaped user name has no commas
+	if strings.Count(clientFirstBare, ",") != 1 {
+		return gs2Header, fmt.Errorf("invalid client-first-message-bare %q", clientFirstBare)
+	}
+	_, clientNonce, _ := strings.Cut(clientFirstBare, ",r=")
+
+	salt := []byte("0123456789abcdef")
+	nonce := clientNonce + "server"
+	serverFirst := fmt.Sprintf("r=%s,s=%s,i=4096", nonce, base64.StdEncoding.EncodeToString(salt))
+	conn.Write(authMessage(11, serverFirst))
+
+	_, body, err = readFrontendMessage(conn)
+	if err != nil {
+		return gs2Header, err
+	}
+	clientFinal := string(body)
+	clientFinalWithoutProof, proofB64, _ := strings.Cut(clientFinal, ",p=")
+
+	binding := []byte(gs2Header)
+	if mechanism == "SCRAM-SHA-256-PLUS" {
+		binding = append(binding, cbData...)
+	}
+	if want := "c=" + base64.StdEncoding.EncodeToString(binding) + ",r=" + nonce; clientFinalWithoutProof != want {
+		return gs2Header, fmt.Errorf("expected %q, got %q", want, clientFinalWithoutProof)
+	}
+
+	saltedPassword := pbkdf2.Key([]byte(password), salt, 4096, 32, sha256.New)
+	hmacSum := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+	message := clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof
+	clientKey := hmacSum(saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	proof, _ := base64.StdEncoding.DecodeString(proofB64)
+	signature := hmacSum(storedKey[:], message)
+	for k := range proof {
+		proof[k] ^= signature[k]
+	}
+	if sum := sha256.Sum256(proof); !hmac.Equal(sum[:], storedKey[:]) {
+		return gs2Header, fmt.Errorf("invalid client proof")
+	}
+
+	serverSignature := hmacSum(hmacSum(saltedPassword, "Server Key"), message)
+	conn.Write(authMessage(12, "v="+base64.StdEncoding.EncodeToString(serverSignature)))
+	return gs2Header, nil
+}
+
+func TestChannelBinding(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	ca := newTestCA(t)
+	serverCert, serverKey := ca.issue(t, 2, "localhost", x509.ExtKeyUsageServerAuth)
+	cbData := sha256.Sum256(serverCert.Raw)
+	config := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+	}
+
+	tests := []struct {
+		name       string
+		useSSL     bool
+		mechanisms []string
+		params     string
+		gs2Header  string
+		expectErr  bool
+	}{
+		{"prefer uses channel binding", true, []string{"SCRAM-SHA-256-PLUS", "SCRAM-SHA-256"}, "channel_binding=prefer", "p=tls-server-end-point,,", false},
+		{"require uses channel binding", true, []string{"SCRAM-SHA-256-PLUS", "SCRAM-SHA-256"}, "channel_binding=require", "p=tls-server-end-point,,", false},
+		{"disable", true, []string{"SCRAM-SHA-256-PLUS", "SCRAM-SHA-256"}, "channel_binding=disable", "n,,", false},
+		{"server without channel binding", true, []string{"SCRAM-SHA-256"}, "channel_binding=prefer", "y,,", false},
+		{"require without server support", true, []string{"SCRAM-SHA-256"}, "channel_binding=require", "", true},
+		{"prefer without SSL", false, []string{"SCRAM-SHA-256"}, "channel_binding=prefer", "n,,", false},
+		{"require without SSL", false, []string{"SCRAM-SHA-256"}, "channel_binding=require", "", true},
+		{"invalid value", false, []string{"SCRAM-SHA-256"}, "channel_binding=on", "", true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			gs2Header := make(chan string, 1)
+			server := mockServer{
+				auth: func(conn net.Conn) error {
+					header, err := serveSCRAM(conn, tc.mechanisms, "secret", cbData[:])
+					gs2Header <- header
+					return err
+				},
+			}
+			sslMode := "disable"
+			if tc.useSSL {
+				server.tls = config
+				sslMode = "require"
+			}
+			port := server.start(t).port
+
+			connStr := fmt.Sprintf("host=localhost port=%s user=postgres dbname=postgres password=secret sslmode=%s %s", port, sslMode, tc.params)
+			conn, err := protocol.NewPgConnection(connStr, models.DriveConfig{})
+			if err == nil {
+				conn.Close()
+			}
+
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error = %v, got %v", tc.expectErr, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			if got := <-gs2Header; got != tc.gs2Header {
+				t.Errorf("expected GS2 header %q, got %q", tc.gs2Header, got)
+			}
+		})
+	}
+}
+
+func TestChannelBindingRequireWithPassword(t *testing.T) {
+	passwordSent := make(chan bool, 1)
+	addr := mockServer{
+		auth: func(conn net.Conn) error {
+			conn.Write(authMessage(3, ""))
+			identifier, _, err := readFrontendMessage(conn)
+			passwordSent <- err == nil && identifier == 'p'
+			return fmt.Errorf("no password expected")
+		},
+	}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres:secret@"+addr+"/postgres?channel_binding=require", models.DriveConfig{})
+	if err == nil {
+		conn.Close()
+		t.Fatal("expected an error")
+	}
+	if <-passwordSent {
+		t.Error("password sent despite channel_binding=require")
+	}
+}
+
+func TestSCRAMSASLprep(t *testing.T) {
+	port := mockServer{
+		auth: func(conn net.Conn) error {
+			// the server prepares the password with SASLprep
+			_, err := serveSCRAM(conn, []string{"SCRAM-SHA-256"}, "IXIX", nil)
+			return err
+		},
+	}.start(t).port
+
+	connStr := "host=127.0.0.1 port=" + port + " user=a,b=c dbname=postgres password=I\u00ADX\u2168"
+	conn, err := protocol.NewPgConnection(connStr, models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,109 @@
+package protocol_test
+
+import (
+	"encoding/binary"
+	"net"
+	"postgres-protocol-go/internal/protocol"
+	"postgres-protocol-go/pkg/models"
+	"postgres-protocol-go/pkg/types"
+	"strings"
+	"testing"
+)
+
+// textRows returns the reply to a query returning rows of text columns.
+func textRows(columns []string, rows [][]string) []byte {
+	description := binary.BigEndian.AppendUint16(nil, uint16(len(columns)))
+	for _, column := range columns {
+		description = append(description, column+"\x00"...)
+		description = binary.BigEndian.AppendUint32(description, 0)
+		description = binary.BigEndian.AppendUint16(description, 0)
+		description = binary.BigEndian.AppendUint32(description, 25)
+		description = binary.BigEndian.AppendUint16(description, 0xffff)
+		description = binary.BigEndian.AppendUint32(description, 0xffffffff)
+		description = binary.BigEndian.AppendUint16(description, 0)
+	}
+
+	reply := backendMessage('T', description)
+	for _, row := range rows {
+		data := binary.BigEndian.AppendUint16(nil, uint16(len(row)))
+		for _, value := range row {
+			data = binary.BigEndian.AppendUint32(data, uint32(len(value)))
+			data = append(data, value...)
+		}
+		reply = append(reply, backendMessage('D', data)...)
+	}
+	reply = append(reply, backendMessage('C', []byte("SELECT\x00"))...)
+	return append(reply, backendMessage('Z', []byte{'I'})...)
+}
+
+func TestLoadTypes(t *testing.T) {
+	enums := textRows([]string{"enumtypid", "enumlabel"}, [][]string{
+		{"16400", "happy"},
+		{"16400", "sad"},
+	})
+	userTypes := textRows(
+		[]string{"oid", "name", "typtype", "typcategory", "typbasetype", "typelem", "typarray", "rngsubtype", "rngtypid"},
+		[][]string{
+			{"16399", "mood[]", "b", "A", "0", "16400", "0", "0", "0"},
+			{"16400", "mood", "e", "E", "0", "0", "16399", "0", "0"},
+			// a domain over int4[] is in the array category
+			{"16410", "int_list", "d", "A", "1007", "0", "16409", "0", "0"},
+			{"16420", "floatrange", "r", "R", "0", "0", "16421", "701", "0"},
+			{"16425", "floatmultirange", "m", "R", "0", "0", "16426", "0", "16420"},
+		})
+
+	addr := mockServer{
+		params: map[string]string{"server_version": "16.2"},
+		reply: func(conn net.Conn, identifier byte, body []byte) {
+			if identifier != 'Q' {
+				return
+			}
+			if strings.Contains(string(body), "pg_enum") {
+				conn.Write(enums)
+			} else {
+				conn.Write(userTypes)
+			}
+		},
+	}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := conn.LoadTypes(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		oid     uint32
+		kind    types.TypeKind
+		baseOID uint32
+	}{
+		{16399, types.ArrayKind, 16400},
+		{16400, types.EnumKind, 0},
+		{16410, types.DomainKind, 1007},
+		{16420, types.RangeKind, 701},
+		{16425, types.MultirangeKind, 16420},
+	}
+	for _, tc := range tests {
+		typ, ok := conn.TypeRegistry().TypeByOID(tc.oid)
+		if !ok {
+			t.Errorf("type %d not registered", tc.oid)
+			continue
+		}
+		if typ.Kind != tc.kind || typ.BaseOID != tc.baseOID {
+			t.Errorf("type %s: expected kind %c and base %d, got %c and %d", typ.Name, tc.kind, tc.baseOID, typ.Kind, typ.BaseOID)
+		}
+	}
+
+	multirange, _ := conn.TypeRegistry().TypeByOID(16425)
+	v, err := multirange.Decode([]byte("{[1.5,2.5)}"), types.TextFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := v.(types.Multirange[interface{}]); !ok || len(m) != 1 {
+		t.Errorf("expected a multirange of one range, got %#v", v)
+	}
+}
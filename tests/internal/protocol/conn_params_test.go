@@ -0,0 +1,103 @@
+package protocol_test
+
+import (
+	"postgres-protocol-go/internal/protocol"
+	"postgres-protocol-go/pkg/models"
+	"testing"
+)
+
+func TestConnParams(t *testing.T) {
+	server := mockServer{}.start(t)
+	port := server.port
+
+	for _, env := range []string{"PGHOST", "PGPORT", "PGUSER", "PGDATABASE", "PGPASSWORD", "PGSSLMODE", "PGAPPNAME", "PGOPTIONS", "PGCONNECT_TIMEOUT"} {
+		t.Setenv(env, "")
+	}
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		connStr  string
+		expected map[string]string
+	}{
+		{
+			name:     "environment",
+			env:      map[string]string{"PGHOST": "127.0.0.1", "PGPORT": port, "PGUSER": "alice", "PGDATABASE": "app", "PGAPPNAME": "worker", "PGOPTIONS": "-c search_path=app", "PGSSLMODE": "disable", "PGCONNECT_TIMEOUT": "5"},
+			connStr:  "",
+			expected: map[string]string{"user": "alice", "database": "app", "application_name": "worker", "options": "-c search_path=app"},
+		},
+		{
+			name:     "connection string overrides environment",
+			env:      map[string]string{"PGHOST": "127.0.0.1", "PGUSER": "alice", "PGDATABASE": "app", "PGAPPNAME": "worker"},
+			connStr:  "port=" + port + " user=bob application_name=api",
+			expected: map[string]string{"user": "bob", "database": "app", "application_name": "api"},
+		},
+		{
+			name:     "URL overrides environment",
+			env:      map[string]string{"PGUSER": "alice", "PGDATABASE": "app"},
+			connStr:  "postgres://bob@127.0.0.1:" + port + "?application_name=api",
+			expected: map[string]string{"user": "bob", "database": "app", "application_name": "api"},
+		},
+		{
+			name:     "database defaults to user",
+			env:      map[string]string{"PGPORT": port},
+			connStr:  "host=127.0.0.1 user=alice",
+			expected: map[string]string{"user": "alice", "database": "alice"},
+		},
+		{
+			name:     "quoted values",
+			connStr:  `host = 127.0.0.1 port=` + port + ` user=alice options='-c search_path=app -c application_name=\'it\'\'s\''`,
+			expected: map[string]string{"user": "alice", "database": "alice", "options": `-c search_path=app -c application_name='it''s'`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			conn, err := protocol.NewPgConnection(tc.connStr, models.DriveConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn.Close()
+
+			startup := (<-server.conns).startup
+			for key, value := range tc.expected {
+				if startup[key] != value {
+					t.Errorf("expected %s %q, got %q", key, value, startup[key])
+				}
+			}
+			if len(startup) != len(tc.expected) {
+				t.Errorf("expected parameters %v, got %v", tc.expected, startup)
+			}
+		})
+	}
+}
+
+func TestConnParamsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		connStr string
+	}{
+		{"invalid PGSSLMODE", map[string]string{"PGSSLMODE": "always"}, "host=127.0.0.1"},
+		{"invalid PGCONNECT_TIMEOUT", map[string]string{"PGCONNECT_TIMEOUT": "soon"}, "host=127.0.0.1"},
+		{"missing equals sign", nil, "host 127.0.0.1"},
+		{"unterminated quote", nil, "host='127.0.0.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+			conn, err := protocol.NewPgConnection(tc.connStr, models.DriveConfig{})
+			if err == nil {
+				conn.Close()
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package protocol_test
+
+import (
+	"errors"
+	"postgres-protocol-go/internal/protocol"
+	"postgres-protocol-go/pkg/models"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	var reply []byte
+	reply = append(reply, backendMessage('1', nil)...)
+	reply = append(reply, backendMessage('t', []byte{0, 1, 0, 0, 0, 23})...)
+	row := []byte{0, 1}
+	row = append(row, "id\x00"...)
+	row = append(row, 0, 0, 0x40, 0, 0, 1, 0, 0, 0, 20, 0, 8, 0xff, 0xff, 0xff, 0xff, 0, 0)
+	reply = append(reply, backendMessage('T', row)...)
+	reply = append(reply, backendMessage('Z', []byte{'I'})...)
+
+	addr := mockServer{reply: replyWith(reply)}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	paramOIDs, fields, err := conn.Describe("SELECT id FROM t WHERE id = $1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paramOIDs) != 1 || paramOIDs[0] != 23 {
+		t.Fatalf("Describe param OIDs = %v", paramOIDs)
+	}
+	if len(fields) != 1 || fields[0].Name != "id" || fields[0].DataTypeOID != 20 || fields[0].TableOID != 0x4000 || fields[0].DataTypeName != "int8" {
+		t.Fatalf("Describe fields = %+v", fields)
+	}
+}
+
+func TestDescribeError(t *testing.T) {
+	errorFields := "SERROR\x00VERROR\x00C42601\x00Msyntax error at or near \"SELEC\"\x00P1\x00\x00"
+	var reply []byte
+	reply = append(reply, backendMessage('E', []byte(errorFields))...)
+	reply = append(reply, backendMessage('Z', []byte{'I'})...)
+
+	addr := mockServer{reply: replyWith(reply)}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, _, err = conn.Describe("SELEC 1")
+	var pgErr *models.PgError
+	if !errors.As(err, &pgErr) {
+		t.Fatalf("Describe error = %v, want *models.PgError", err)
+	}
+	if pgErr.Code != "42601" || pgErr.Position != 1 || pgErr.Severity != "ERROR" {
+		t.Fatalf("Describe error = %+v", pgErr)
+	}
+
+	// the connection is ready for the next statement
+	if _, _, err := conn.Describe("SELEC 1"); !errors.As(err, &pgErr) {
+		t.Fatalf("second Describe error = %v", err)
+	}
+}
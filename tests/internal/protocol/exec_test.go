@@ -0,0 +1,163 @@
+package protocol_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"postgres-protocol-go/internal/protocol"
+	"postgres-protocol-go/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExec(t *testing.T) {
+	var reply []byte
+	reply = append(reply, backendMessage('C', []byte("UPDATE 3\x00"))...)
+	reply = append(reply, backendMessage('Z', []byte{'I'})...)
+
+	addr := mockServer{reply: replyWith(reply)}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	tag, err := conn.Exec(context.Background(), "UPDATE t SET a = 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Command() != "UPDATE" || tag.RowsAffected() != 3 {
+		t.Fatalf("Exec tag = %q", tag)
+	}
+}
+
+func TestQueryRow(t *testing.T) {
+	fields := []byte{0, 2}
+	fields = append(fields, "id\x00"...)
+	fields = append(fields, 0, 0, 0, 0, 0, 0, 0, 0, 0, 23, 0, 4, 0xff, 0xff, 0xff, 0xff, 0, 0)
+	fields = append(fields, "name\x00"...)
+	fields = append(fields, 0, 0, 0, 0, 0, 0, 0, 0, 0, 25, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0, 0)
+
+	var reply []byte
+	reply = append(reply, backendMessage('T', fields)...)
+	reply = append(reply, backendMessage('D', []byte{0, 2, 0, 0, 0, 1, '7', 0, 0, 0, 3, 'a', 'b', 'c'})...)
+	reply = append(reply, backendMessage('D', []byte{0, 2, 0, 0, 0, 1, '8', 0, 0, 0, 1, 'd'})...)
+	reply = append(reply, backendMessage('C', []byte("SELECT 2\x00"))...)
+	reply = append(reply, backendMessage('Z', []byte{'I'})...)
+
+	addr := mockServer{reply: replyWith(reply)}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var id int
+	var name *string
+	if err := conn.QueryRow(context.Background(), "SELECT id, name FROM t").Scan(&id, &name); err != nil {
+		t.Fatal(err)
+	}
+	if id != 7 || name == nil || *name != "abc" {
+		t.Fatalf("Scan = %d, %v", id, name)
+	}
+}
+
+func TestQueryRowNoRows(t *testing.T) {
+	var reply []byte
+	reply = append(reply, backendMessage('T', []byte{0, 0})...)
+	reply = append(reply, backendMessage('C', []byte("SELECT 0\x00"))...)
+	reply = append(reply, backendMessage('Z', []byte{'I'})...)
+
+	addr := mockServer{reply: replyWith(reply)}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	err = conn.QueryRow(context.Background(), "SELECT 1 WHERE false").Scan()
+	if !errors.Is(err, models.ErrNoRows) {
+		t.Fatalf("Scan error = %v, want ErrNoRows", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = conn.Exec(ctx, "SELECT 1")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Exec error = %v, want context.Canceled", err)
+	}
+}
+
+func TestExecCancel(t *testing.T) {
+	canceled := "SERROR\x00VERROR\x00C57014\x00Mcanceling statement due to user request\x00\x00"
+	var reply []byte
+	reply = append(reply, backendMessage('C', []byte("SELECT 1\x00"))...)
+	reply = append(reply, backendMessage('Z', []byte{'I'})...)
+
+	queries := make(chan net.Conn, 1)
+	keys := make(chan []byte, 1)
+	addr := mockServer{
+		reply: func(conn net.Conn, identifier byte, body []byte) {
+			switch {
+			case identifier == 'Q' && strings.Contains(string(body), "pg_sleep"):
+				queries <- conn
+			case identifier == 'Q':
+				conn.Write(reply)
+			}
+		},
+		cancel: func(key []byte) {
+			keys <- key
+			conn := <-queries
+			conn.Write(backendMessage('E', []byte(canceled)))
+			conn.Write(backendMessage('Z', []byte{'I'}))
+		},
+	}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = conn.Exec(ctx, "SELECT pg_sleep(10)")
+	var pgErr *models.PgError
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &pgErr) || pgErr.Code != "57014" {
+		t.Fatalf("Exec error = %v, want a canceled query", err)
+	}
+	if key := <-keys; !bytes.Equal(key, mockBackendKey) {
+		t.Errorf("expected the cancel request to carry the backend key, got %v", key)
+	}
+
+	// the canceled query left the connection ready
+	if _, err := conn.Exec(context.Background(), "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExecInterrupted(t *testing.T) {
+	// queries are never answered and no cancellation key is sent
+	addr := mockServer{reply: func(net.Conn, byte, []byte) {}}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := conn.Exec(ctx, "SELECT pg_sleep(10)"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Exec error = %v, want context.DeadlineExceeded", err)
+	}
+
+	if _, err := conn.Exec(context.Background(), "SELECT 1"); !errors.Is(err, models.ErrConnBroken) {
+		t.Fatalf("Exec error = %v, want models.ErrConnBroken", err)
+	}
+}
@@ -0,0 +1,173 @@
+package protocol_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"postgres-protocol-go/internal/protocol"
+	"postgres-protocol-go/pkg/models"
+	"postgres-protocol-go/pkg/types"
+	"testing"
+	"time"
+)
+
+// boundStatement is a statement parsed and bound by the client.
+type boundStatement struct {
+	// paramOIDs are the parameter types declared in Parse.
+	paramOIDs []uint32
+	// formats and values are the parameters of Bind.
+	formats []int16
+	values  [][]byte
+}
+
+// describedParams returns a mockServer describing the parameters of each
+// statement as paramOIDs, or as the types declared in Parse, and sending the
+// statements it binds to statements.
+func describedParams(paramOIDs []uint32, statements chan<- boundStatement) mockServer {
+	var statement boundStatement
+	return mockServer{
+		reply: func(conn net.Conn, identifier byte, body []byte) {
+			switch identifier {
+			case 'P':
+				statement = boundStatement{paramOIDs: parseParamOIDs(body)}
+			case 'H':
+				description := binary.BigEndian.AppendUint16(nil, uint16(len(paramOIDs)))
+				for i, oid := range paramOIDs {
+					if i < len(statement.paramOIDs) && statement.paramOIDs[i] != 0 {
+						oid = statement.paramOIDs[i]
+					}
+					description = binary.BigEndian.AppendUint32(description, oid)
+				}
+				conn.Write(backendMessage('1', nil))
+				conn.Write(backendMessage('t', description))
+				conn.Write(backendMessage('n', nil))
+			case 'B':
+				statement.formats, statement.values = parseBindParams(body)
+				statements <- statement
+			case 'S':
+				conn.Write(backendMessage('2', nil))
+				conn.Write(backendMessage('C', []byte("SELECT 0\x00")))
+				conn.Write(backendMessage('Z', []byte{'I'}))
+			}
+		},
+	}
+}
+
+func parseParamOIDs(body []byte) []uint32 {
+	// skip the statement name and the query
+	body = body[bytes.IndexByte(body, 0)+1:]
+	body = body[bytes.IndexByte(body, 0)+1:]
+
+	oids := make([]uint32, binary.BigEndian.Uint16(body))
+	for i := range oids {
+		oids[i] = binary.BigEndian.Uint32(body[2+4*i:])
+	}
+	return oids
+}
+
+func parseBindParams(body []byte) ([]int16, [][]byte) {
+	// skip the portal and the statement name
+	body = body[bytes.IndexByte(body, 0)+1:]
+	body = body[bytes.IndexByte(body, 0)+1:]
+
+	formats := make([]int16, binary.BigEndian.Uint16(body))
+	body = body[2:]
+	for i := range formats {
+		formats[i] = int16(binary.BigEndian.Uint16(body))
+		body = body[2:]
+	}
+
+	values := make([][]byte, binary.BigEndian.Uint16(body))
+	body = body[2:]
+	for i := range values {
+		length := int32(binary.BigEndian.Uint32(body))
+		body = body[4:]
+		if length >= 0 {
+			values[i], body = body[:length], body[length:]
+		}
+	}
+	return formats, values
+}
+
+func TestTimeRangeParam(t *testing.T) {
+	statements := make(chan boundStatement, 1)
+	addr := describedParams([]uint32{types.DateRangeOID}, statements).start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := types.NewRange(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if _, err := conn.Query("SELECT * FROM bookings WHERE during && $1", r); err != nil {
+		t.Fatal(err)
+	}
+
+	statement := <-statements
+	if statement.paramOIDs[0] != 0 {
+		t.Errorf("expected the range type to be inferred, declared %d", statement.paramOIDs[0])
+	}
+	if statement.formats[0] != types.TextFormat {
+		t.Fatalf("expected a text range, got format %d", statement.formats[0])
+	}
+	if want := string(types.Append(nil, r, 0)); string(statement.values[0]) != want {
+		t.Errorf("expected %s, got %s", want, statement.values[0])
+	}
+}
+
+func TestParamInferredType(t *testing.T) {
+	statements := make(chan boundStatement, 1)
+	addr := describedParams([]uint32{types.TextOID, types.Int4OID, types.TimestampOID}, statements).start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	tm := time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+	if _, err := conn.Query("SELECT * FROM t WHERE name = $1 AND id = $2 AND created = $3", 42, int64(7), tm); err != nil {
+		t.Fatal(err)
+	}
+
+	statement := <-statements
+	for i, oid := range statement.paramOIDs {
+		if oid != 0 {
+			t.Errorf("expected $%d to be inferred, declared %d", i+1, oid)
+		}
+	}
+	if statement.formats[0] != types.TextFormat || string(statement.values[0]) != "42" {
+		t.Errorf("expected the text 42, got %q in format %d", statement.values[0], statement.formats[0])
+	}
+	if statement.formats[1] != types.BinaryFormat || !bytes.Equal(statement.values[1], []byte{0, 0, 0, 7}) {
+		t.Errorf("expected a binary int4, got %v in format %d", statement.values[1], statement.formats[1])
+	}
+	if statement.formats[2] != types.BinaryFormat || len(statement.values[2]) != 8 {
+		t.Errorf("expected a binary timestamp, got %v in format %d", statement.values[2], statement.formats[2])
+	}
+}
+
+func TestPinParamTypes(t *testing.T) {
+	statements := make(chan boundStatement, 1)
+	// the server infers text for SELECT $1
+	addr := describedParams([]uint32{types.TextOID}, statements).start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{PinParamTypes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Query("SELECT $1", int64(7)); err != nil {
+		t.Fatal(err)
+	}
+
+	statement := <-statements
+	if statement.paramOIDs[0] != types.Int8OID {
+		t.Errorf("expected $1 to be declared as int8, declared %d", statement.paramOIDs[0])
+	}
+	if statement.formats[0] != types.BinaryFormat || !bytes.Equal(statement.values[0], []byte{0, 0, 0, 0, 0, 0, 0, 7}) {
+		t.Errorf("expected a binary int8, got %v in format %d", statement.values[0], statement.formats[0])
+	}
+}
@@ -0,0 +1,255 @@
+package protocol_test
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// sslRequestCode and cancelRequestCode are the protocol versions of an
+// SSLRequest and a CancelRequest.
+const (
+	sslRequestCode    = 80877103
+	cancelRequestCode = 80877102
+)
+
+// mockBackendKey is the BackendKeyData sent by a mockServer with a cancel
+// function: process ID 1234 and a 4-byte secret key.
+var mockBackendKey = []byte{0, 0, 0x04, 0xd2, 's', 'e', 'c', 'r'}
+
+// mockServer is a scripted PostgreSQL server. The zero value listens on a
+// local TCP port, refuses SSL, trusts every user and answers each Sync or
+// Query with ReadyForQuery.
+type mockServer struct {
+	// network and address to listen on, tcp on 127.0.0.1:0 by default.
+	network string
+	address string
+	// tls serves connections over TLS after their SSLRequest, or right away
+	// with directTLS.
+	tls       *tls.Config
+	directTLS bool
+	// silent accepts connections and never answers them.
+	silent bool
+	// auth authenticates a connection after its startup message, before
+	// AuthenticationOk. An error closes the connection.
+	auth func(conn net.Conn) error
+	// params are sent in ParameterStatus messages after authentication.
+	params map[string]string
+	// reply answers each message sent after startup.
+	reply func(conn net.Conn, identifier byte, body []byte)
+	// cancel receives the process ID and secret key of each CancelRequest.
+	// Without it, no BackendKeyData is sent.
+	cancel func(key []byte)
+}
+
+// mockConn describes a connection accepted by a mockServer.
+type mockConn struct {
+	// startup holds the parameters of the startup message.
+	startup map[string]string
+	// tls is the state of the TLS connection, nil without TLS.
+	tls *tls.ConnectionState
+}
+
+// mockListener is a running mockServer, closed when the test ends.
+type mockListener struct {
+	addr string
+	port string
+	// conns receives each connection after its startup message.
+	conns chan mockConn
+}
+
+func (s mockServer) start(t *testing.T) *mockListener {
+	network, address := s.network, s.address
+	if network == "" {
+		network, address = "tcp", "127.0.0.1:0"
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatalf("Failed to start mock server: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	m := &mockListener{addr: listener.Addr().String(), conns: make(chan mockConn, 64)}
+	_, m.port, _ = net.SplitHostPort(m.addr)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				s.serve(conn, m.conns)
+			}()
+		}
+	}()
+	return m
+}
+
+func (s mockServer) serve(conn net.Conn, conns chan<- mockConn) {
+	if s.silent {
+		io.Copy(io.Discard, conn)
+		return
+	}
+
+	var state *tls.ConnectionState
+	startTLS := func() bool {
+		tlsConn := tls.Server(conn, s.tls)
+		if err := tlsConn.Handshake(); err != nil {
+			return false
+		}
+		connState := tlsConn.ConnectionState()
+		conn, state = tlsConn, &connState
+		return true
+	}
+	if s.directTLS && !startTLS() {
+		return
+	}
+
+	var startup map[string]string
+	for startup == nil {
+		code, body, err := readStartupMessage(conn)
+		if err != nil {
+			return
+		}
+		if code == cancelRequestCode {
+			if s.cancel != nil {
+				s.cancel(body)
+			}
+			return
+		}
+		if code != sslRequestCode {
+			startup = parseStartupParams(body)
+			break
+		}
+		if s.tls == nil || state != nil {
+			conn.Write([]byte{'N'})
+			continue
+		}
+		conn.Write([]byte{'S'})
+		if !startTLS() {
+			return
+		}
+	}
+
+	select {
+	case conns <- mockConn{startup: startup, tls: state}:
+	default:
+	}
+
+	if s.auth != nil {
+		if err := s.auth(conn); err != nil {
+			return
+		}
+	}
+	conn.Write(authMessage(0, ""))
+	for name, value := range s.params {
+		conn.Write(backendMessage('S', []byte(name+"\x00"+value+"\x00")))
+	}
+	if s.cancel != nil {
+		conn.Write(backendMessage('K', mockBackendKey))
+	}
+	conn.Write(backendMessage('Z', []byte{'I'}))
+
+	for {
+		identifier, body, err := readFrontendMessage(conn)
+		if err != nil {
+			return
+		}
+		switch {
+		case s.reply != nil:
+			s.reply(conn, identifier, body)
+		case identifier == 'S' || identifier == 'Q':
+			conn.Write(backendMessage('Z', []byte{'I'}))
+		}
+	}
+}
+
+// replyWith returns a reply answering each Sync or Query with reply.
+func replyWith(reply []byte) func(conn net.Conn, identifier byte, body []byte) {
+	return func(conn net.Conn, identifier byte, body []byte) {
+		if identifier == 'S' || identifier == 'Q' {
+			conn.Write(reply)
+		}
+	}
+}
+
+// readStartupMessage reads a message without an identifier byte and returns
+// its protocol version or request code.
+func readStartupMessage(conn net.Conn) (uint32, []byte, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header)
+	if length < 8 || length > 10000 {
+		return 0, nil, fmt.Errorf("invalid startup message length %d", length)
+	}
+	body := make([]byte, length-8)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return 0, nil, err
+	}
+	return binary.BigEndian.Uint32(header[4:]), body, nil
+}
+
+// parseStartupParams returns the name-value pairs of a startup message.
+func parseStartupParams(body []byte) map[string]string {
+	params := map[string]string{}
+	fields := strings.Split(string(body), "\x00")
+	for i := 0; i+1 < len(fields) && fields[i] != ""; i += 2 {
+		params[fields[i]] = fields[i+1]
+	}
+	return params
+}
+
+// readFrontendMessage reads a message with an identifier byte.
+func readFrontendMessage(conn net.Conn) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return 0, nil, err
+	}
+	return header[0], body, nil
+}
+
+func backendMessage(identifier byte, body []byte) []byte {
+	message := []byte{identifier, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(message[1:], uint32(len(body)+4))
+	return append(message, body...)
+}
+
+func authMessage(authType uint32, data string) []byte {
+	body := binary.BigEndian.AppendUint32(nil, authType)
+	return backendMessage('R', append(body, data...))
+}
+
+// valueRow returns the reply to a query returning a single value of type oid
+// in text format.
+func valueRow(oid uint32, value string) []byte {
+	field := []byte("value\x00")
+	field = binary.BigEndian.AppendUint32(field, 0)
+	field = binary.BigEndian.AppendUint16(field, 0)
+	field = binary.BigEndian.AppendUint32(field, oid)
+	field = binary.BigEndian.AppendUint16(field, 0xffff)
+	field = binary.BigEndian.AppendUint32(field, 0xffffffff)
+	field = binary.BigEndian.AppendUint16(field, 0)
+
+	row := binary.BigEndian.AppendUint16(nil, 1)
+	row = binary.BigEndian.AppendUint32(row, uint32(len(value)))
+	row = append(row, value...)
+
+	var reply []byte
+	reply = append(reply, backendMessage('T', append([]byte{0, 1}, field...))...)
+	reply = append(reply, backendMessage('D', row)...)
+	reply = append(reply, backendMessage('C', []byte("SELECT 1\x00"))...)
+	reply = append(reply, backendMessage('Z', []byte{'I'})...)
+	return reply
+}
@@ -1,6 +1,7 @@
 package protocol_test
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"postgres-protocol-go/internal/protocol"
@@ -8,38 +9,15 @@ import (
 	"testing"
 )
 
-// todo: improve mock server to handle authentication
-func startMockPostgresServer(t *testing.T, authSuccess bool) (string, func()) {
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatalf("Failed to start mock server: %v", err)
-	}
-
-	addr := listener.Addr().String()
-
-	go func() {
-		for {
-			conn, err := listener.Accept()
-			if err != nil {
-				return
-			}
-			defer conn.Close()
-
-			buffer := make([]byte, 1024)
-			n, _ := conn.Read(buffer)
-
-			if authSuccess {
-				conn.Write([]byte("R\x00\x00\x00\x08\x00\x00\x00\x00")) // Auth OK response
-				conn.Write([]byte("Z\x00\x00\x00\x05I"))                // ReadyForQuery response
-			} else {
-				conn.Write([]byte("E\x00\x00\x00\x12Invalid password")) // Error response
-			}
-
-			conn.Read(buffer[:n])
+func startMockPostgresServer(t *testing.T, authSuccess bool) string {
+	server := mockServer{}
+	if !authSuccess {
+		server.auth = func(conn net.Conn) error {
+			conn.Write(backendMessage('E', []byte("SFATAL\x00C28P01\x00Mpassword authentication failed for user \"postgres\"\x00\x00")))
+			return errors.New("invalid password")
 		}
-	}()
-
-	return addr, func() { listener.Close() }
+	}
+	return server.start(t).addr
 }
 
 func TestNewPgConnection(t *testing.T) {
@@ -63,8 +41,7 @@ func TestNewPgConnection(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			fmt.Println("Running test: ", tc.name)
-			mockAddr, cleanup := startMockPostgresServer(t, tc.authSuccess)
-			defer cleanup()
+			mockAddr := startMockPostgresServer(t, tc.authSuccess)
 
 			connURL := fmt.Sprintf("postgres://postgres:123456@%s/postgres", mockAddr)
 			driveConfig := models.DriveConfig{
@@ -0,0 +1,180 @@
+package protocol_test
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"postgres-protocol-go/internal/protocol"
+	"postgres-protocol-go/pkg/models"
+	"strings"
+	"testing"
+)
+
+// startPasswordServer requests a cleartext password from each connection and
+// sends it on the returned channel.
+func startPasswordServer(t *testing.T) (port string, passwords <-chan string) {
+	ch := make(chan string, 16)
+	port = mockServer{
+		auth: func(conn net.Conn) error {
+			conn.Write(authMessage(3, ""))
+			identifier, body, err := readFrontendMessage(conn)
+			if err != nil {
+				return err
+			}
+			if identifier != 'p' {
+				return fmt.Errorf("expected a password message, got %c", identifier)
+			}
+			ch <- strings.TrimSuffix(string(body), "\x00")
+			return nil
+		},
+	}.start(t).port
+	return port, ch
+}
+
+func TestPassFile(t *testing.T) {
+	port, passwords := startPasswordServer(t)
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PGPASSFILE", "")
+
+	writePassFile := func(t *testing.T, path string, mode os.FileMode, lines ...string) {
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defaultFile := filepath.Join(home, ".pgpass")
+	otherFile := filepath.Join(t.TempDir(), "pgpass")
+
+	tests := []struct {
+		name     string
+		file     string
+		mode     os.FileMode
+		lines    []string
+		env      string
+		params   string
+		expected string
+	}{
+		{
+			name:     "exact match",
+			file:     defaultFile,
+			lines:    []string{"localhost:" + port + ":app:alice:secret"},
+			params:   "dbname=app user=alice",
+			expected: "secret",
+		},
+		{
+			name: "first matching line",
+			file: defaultFile,
+			lines: []string{
+				"# comment",
+				"otherhost:*:*:*:wrong",
+				"localhost:" + port + ":other:*:wrong",
+				"",
+				"*:" + port + ":app:*:first",
+				"*:*:*:*:second",
+			},
+			params:   "dbname=app user=alice",
+			expected: "first",
+		},
+		{
+			name:     "user mismatch",
+			file:     defaultFile,
+			lines:    []string{"*:*:app:bob:wrong", "*:*:app:alice:secret"},
+			params:   "dbname=app user=alice",
+			expected: "secret",
+		},
+		{
+			name:     "database defaults to user",
+			file:     defaultFile,
+			lines:    []string{"*:*:alice:alice:secret", "*:*:*:*:wrong"},
+			params:   "user=alice",
+			expected: "secret",
+		},
+		{
+			name:     "escaped fields and password",
+			file:     defaultFile,
+			lines:    []string{`*:*:db\:1:a\\b:pass\:word\\:ignored`},
+			params:   `dbname=db:1 user=a\\b`,
+			expected: `pass:word\`,
+		},
+		{
+			name:     "escaped star is literal",
+			file:     defaultFile,
+			lines:    []string{`*:*:\*:*:wrong`, "*:*:*:*:secret"},
+			params:   "dbname=app user=alice",
+			expected: "secret",
+		},
+		{
+			name:     "no matching line",
+			file:     defaultFile,
+			lines:    []string{"*:*:other:*:wrong"},
+			params:   "dbname=app user=alice",
+			expected: "",
+		},
+		{
+			name:     "group readable file is ignored",
+			file:     defaultFile,
+			mode:     0640,
+			lines:    []string{"*:*:*:*:secret"},
+			params:   "dbname=app user=alice",
+			expected: "",
+		},
+		{
+			name:     "PGPASSFILE",
+			file:     otherFile,
+			lines:    []string{"*:*:*:*:secret"},
+			env:      otherFile,
+			params:   "dbname=app user=alice",
+			expected: "secret",
+		},
+		{
+			name:     "passfile overrides PGPASSFILE",
+			file:     otherFile,
+			lines:    []string{"*:*:*:*:secret"},
+			env:      filepath.Join(home, "missing"),
+			params:   "dbname=app user=alice passfile=" + otherFile,
+			expected: "secret",
+		},
+		{
+			name:     "password overrides the file",
+			file:     defaultFile,
+			lines:    []string{"*:*:*:*:wrong"},
+			params:   "dbname=app user=alice password=given",
+			expected: "given",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mode := tc.mode
+			if mode == 0 {
+				mode = 0600
+			}
+			writePassFile(t, tc.file, mode, tc.lines...)
+			defer os.Remove(tc.file)
+			t.Setenv("PGPASSFILE", tc.env)
+
+			connStr := fmt.Sprintf("host=localhost port=%s %s", port, tc.params)
+			conn, err := protocol.NewPgConnection(connStr, models.DriveConfig{})
+			if tc.expected == "" {
+				if err == nil {
+					conn.Close()
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn.Close()
+
+			if got := <-passwords; got != tc.expected {
+				t.Errorf("expected password %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
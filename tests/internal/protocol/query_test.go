@@ -0,0 +1,212 @@
+package protocol_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"postgres-protocol-go/internal/protocol"
+	"postgres-protocol-go/pkg/models"
+	"postgres-protocol-go/pkg/types"
+	"reflect"
+	"testing"
+)
+
+func TestQueryDuplicateColumnNames(t *testing.T) {
+	var fields []byte
+	fields = append(fields, 0, 2)
+	for _, name := range []string{"id", "id"} {
+		fields = append(fields, name+"\x00"...)
+		fields = append(fields, 0, 0, 0, 0, 0, 0, 0, 0, 0, 23, 0, 4, 0xff, 0xff, 0xff, 0xff, 0, 0)
+	}
+	row := []byte{0, 2, 0, 0, 0, 1, '1', 0, 0, 0, 1, '2'}
+
+	var reply []byte
+	reply = append(reply, backendMessage('T', fields)...)
+	reply = append(reply, backendMessage('D', row)...)
+	reply = append(reply, backendMessage('C', []byte("SELECT 1\x00"))...)
+	reply = append(reply, backendMessage('Z', []byte{'I'})...)
+
+	addr := mockServer{reply: replyWith(reply)}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	res, err := conn.Query("/* report */ WITH x AS (SELECT 1) SELECT a.id, b.id FROM a JOIN b USING (k)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Command != "SELECT" || res.RowsAffected() != 1 {
+		t.Fatalf("Command = %q, RowsAffected = %d", res.Command, res.RowsAffected())
+	}
+	if len(res.Values) != 1 || res.Values[0][0] != "1" || res.Values[0][1] != "2" {
+		t.Fatalf("Values = %v", res.Values)
+	}
+	if v, ok := res.Value(0, "id"); !ok || v != "1" {
+		t.Fatalf("Value(0, id) = %v, %v", v, ok)
+	}
+	if res.FieldIndex("missing") != -1 {
+		t.Fatal("FieldIndex of a missing column should be -1")
+	}
+}
+
+func TestQueryRaw(t *testing.T) {
+	fields := []byte{0, 2}
+	for _, name := range []string{"a", "b"} {
+		fields = append(fields, name+"\x00"...)
+		fields = append(fields, 0, 0, 0, 0, 0, 0, 0, 0, 0, 25, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0, 0)
+	}
+
+	var reply []byte
+	reply = append(reply, backendMessage('T', fields)...)
+	reply = append(reply, backendMessage('D', []byte{0, 2, 0, 0, 0, 2, 'x', 'y', 0xff, 0xff, 0xff, 0xff})...)
+	reply = append(reply, backendMessage('D', []byte{0, 2, 0, 0, 0, 0, 0, 0, 0, 1, 'z'})...)
+	reply = append(reply, backendMessage('C', []byte("SELECT 2\x00"))...)
+	reply = append(reply, backendMessage('Z', []byte{'I'})...)
+
+	addr := mockServer{reply: replyWith(reply)}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var got [][]string
+	res, err := conn.QueryRaw("SELECT a, b FROM t", func(fields []models.Field, row [][]byte) error {
+		if len(fields) != 2 {
+			t.Fatalf("QueryRaw fields = %v", fields)
+		}
+		var values []string
+		for _, value := range row {
+			if value == nil {
+				values = append(values, "NULL")
+			} else {
+				values = append(values, string(value))
+			}
+		}
+		got = append(got, values)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowCount != 2 || res.Command != "SELECT" || len(res.Rows) != 0 {
+		t.Fatalf("QueryRaw result = %+v", res)
+	}
+	if len(got) != 2 || got[0][0] != "xy" || got[0][1] != "NULL" || got[1][0] != "" || got[1][1] != "z" {
+		t.Fatalf("QueryRaw rows = %q", got)
+	}
+}
+
+func TestQueryColumnar(t *testing.T) {
+	fields := []byte{0, 3}
+	for _, field := range []struct {
+		name string
+		oid  uint32
+	}{{"n", types.Int8OID}, {"avg", types.NumericOID}, {"label", types.TextOID}} {
+		fields = append(fields, field.name+"\x00"...)
+		fields = append(fields, 0, 0, 0, 0, 0, 0)
+		fields = binary.BigEndian.AppendUint32(fields, field.oid)
+		fields = append(fields, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0, 0)
+	}
+
+	var reply []byte
+	reply = append(reply, backendMessage('T', fields)...)
+	reply = append(reply, backendMessage('D', []byte{0, 3, 0, 0, 0, 2, '4', '2', 0, 0, 0, 3, '1', '.', '5', 0, 0, 0, 1, 'a'})...)
+	reply = append(reply, backendMessage('D', []byte{0, 3, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 1, '2', 0xff, 0xff, 0xff, 0xff})...)
+	reply = append(reply, backendMessage('C', []byte("SELECT 2\x00"))...)
+	reply = append(reply, backendMessage('Z', []byte{'I'})...)
+
+	addr := mockServer{reply: replyWith(reply)}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	res, err := conn.QueryColumnar("SELECT n, avg, label FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowCount != 2 || len(res.Columns) != 3 {
+		t.Fatalf("QueryColumnar result = %+v", res)
+	}
+
+	n := res.Column("n")
+	if len(n.Int64s) != 2 || n.Int64s[0] != 42 || n.IsNull(0) || !n.IsNull(1) {
+		t.Fatalf("column n = %+v", n)
+	}
+	avg := res.Column("avg")
+	if len(avg.Float64s) != 2 || avg.Float64s[0] != 1.5 || avg.Float64s[1] != 2 {
+		t.Fatalf("column avg = %+v", avg)
+	}
+	label := res.Column("label")
+	if len(label.Strings) != 2 || label.Strings[0] != "a" || !label.IsNull(1) {
+		t.Fatalf("column label = %+v", label)
+	}
+}
+
+func TestQueryBuiltinTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		oid   uint32
+		text  string
+		value interface{}
+	}{
+		{"point", types.PointOID, "(1,2)", types.Point{X: 1, Y: 2}},
+		{"line", types.LineOID, "{1,-1,0}", types.Line{A: 1, B: -1, C: 0}},
+		{"lseg", types.LsegOID, "[(0,0),(1,1)]", types.Lseg{P: [2]types.Point{{X: 0, Y: 0}, {X: 1, Y: 1}}}},
+		{"box", types.BoxOID, "(1,1),(0,0)", types.Box{P: [2]types.Point{{X: 1, Y: 1}, {X: 0, Y: 0}}}},
+		{"path", types.PathOID, "[(0,0),(1,1)]", types.Path{Points: []types.Point{{X: 0, Y: 0}, {X: 1, Y: 1}}}},
+		{"polygon", types.PolygonOID, "((0,0),(1,0),(1,1))", types.Polygon{Points: []types.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}}}},
+		{"circle", types.CircleOID, "<(1,2),3>", types.Circle{Center: types.Point{X: 1, Y: 2}, Radius: 3}},
+		{"inet", types.InetOID, "192.168.0.1", netip.MustParsePrefix("192.168.0.1/32")},
+		{"cidr", types.CidrOID, "10.0.0.0/8", netip.MustParsePrefix("10.0.0.0/8")},
+		{"macaddr", types.MacaddrOID, "08:00:2b:01:02:03", net.HardwareAddr{8, 0, 0x2b, 1, 2, 3}},
+		{"macaddr8", types.Macaddr8OID, "08:00:2b:01:02:03:04:05", net.HardwareAddr{8, 0, 0x2b, 1, 2, 3, 4, 5}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			addr := mockServer{reply: replyWith(valueRow(tc.oid, tc.text))}.start(t).addr
+
+			conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			result, err := conn.Query("SELECT value")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value := result.Rows[0]["value"]; !reflect.DeepEqual(value, tc.value) {
+				t.Errorf("expected %#v, got %#v", tc.value, value)
+			}
+		})
+	}
+}
+
+func TestQueryInvalidMessageLength(t *testing.T) {
+	for _, length := range []uint32{0, 3, 0xffffffff} {
+		reply := binary.BigEndian.AppendUint32([]byte{'T'}, length)
+		addr := mockServer{reply: replyWith(reply)}.start(t).addr
+
+		conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres", models.DriveConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.Query("SELECT 1"); err == nil {
+			t.Errorf("expected an error for message length %d", length)
+		}
+		if _, err := conn.Query("SELECT 1"); !errors.Is(err, models.ErrConnBroken) {
+			t.Errorf("expected the connection to be broken after message length %d, got %v", length, err)
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,364 @@
+package protocol_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	pbkdf2 "postgres-protocol-go"
+	"postgres-protocol-go/internal/protocol"
+	"postgres-protocol-go/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns a certificate for dnsName signed by the CA and its key.
+func (ca *testCA) issue(t *testing.T, serial int64, dnsName string, usage x509.ExtKeyUsage) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// encryptPKCS8 encrypts a PKCS#8 key with PBES2, PBKDF2-HMAC-SHA256 and
+// AES-256-CBC, like openssl pkcs8 -topk8 -v2 aes256.
+func encryptPKCS8(t *testing.T, der, password []byte) []byte {
+	salt := make([]byte, 8)
+	iv := make([]byte, aes.BlockSize)
+	rand.Read(salt)
+	rand.Read(iv)
+
+	key := pbkdf2.Key(password, salt, 2048, 32, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	plain := append(append([]byte{}, der...), []byte(strings.Repeat(string(rune(padding)), padding))...)
+	encrypted := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, plain)
+
+	type algorithm struct {
+		Algorithm  asn1.ObjectIdentifier
+		Parameters asn1.RawValue `asn1:"optional"`
+	}
+	marshal := func(v interface{}) asn1.RawValue {
+		b, err := asn1.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return asn1.RawValue{FullBytes: b}
+	}
+
+	kdf := algorithm{
+		Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12},
+		Parameters: marshal(struct {
+			Salt           []byte
+			IterationCount int
+			PRF            algorithm
+		}{salt, 2048, algorithm{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}, Parameters: asn1.NullRawValue}}),
+	}
+	scheme := algorithm{
+		Algorithm:  asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42},
+		Parameters: marshal(iv),
+	}
+	info := struct {
+		Algorithm     algorithm
+		EncryptedData []byte
+	}{
+		Algorithm: algorithm{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13},
+			Parameters: marshal(struct{ KDF, Scheme algorithm }{kdf, scheme}),
+		},
+		EncryptedData: encrypted,
+	}
+	out, err := asn1.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestSSLModes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // no ~/.postgresql files
+	dir := t.TempDir()
+
+	ca := newTestCA(t)
+	rootCert := writePEM(t, dir, "root.crt", "CERTIFICATE", ca.cert.Raw)
+
+	serverCert, serverKey := ca.issue(t, 2, "localhost", x509.ExtKeyUsageServerAuth)
+	otherCert, otherKey := ca.issue(t, 3, "db.example", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, 4, "postgres", x509.ExtKeyUsageClientAuth)
+
+	clientKeyDER, err := x509.MarshalPKCS8PrivateKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sslCert := writePEM(t, dir, "client.crt", "CERTIFICATE", clientCert.Raw)
+	sslKey := writePEM(t, dir, "client.key", "ENCRYPTED PRIVATE KEY", encryptPKCS8(t, clientKeyDER, []byte("secret")))
+
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now().Add(-time.Hour),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{{SerialNumber: big.NewInt(2), RevocationTime: time.Now()}},
+	}, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sslCRL := writePEM(t, dir, "root.crl", "X509 CRL", crl)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	serverConfig := func(cert *x509.Certificate, key *ecdsa.PrivateKey, clientAuth tls.ClientAuthType) *tls.Config {
+		return &tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: key}},
+			ClientAuth:   clientAuth,
+			ClientCAs:    clientCAs,
+		}
+	}
+
+	port := mockServer{tls: serverConfig(serverCert, serverKey, tls.NoClientCert)}.start(t).port
+	otherPort := mockServer{tls: serverConfig(otherCert, otherKey, tls.NoClientCert)}.start(t).port
+	certPort := mockServer{tls: serverConfig(serverCert, serverKey, tls.RequireAndVerifyClientCert)}.start(t).port
+
+	tests := []struct {
+		name      string
+		port      string
+		params    string
+		expectErr bool
+	}{
+		{"require without root certificate", otherPort, "sslmode=require", false},
+		{"verify-full", port, "sslmode=verify-full sslrootcert=" + rootCert, false},
+		{"verify-full with client certificate", certPort, fmt.Sprintf("sslmode=verify-full sslrootcert=%s sslcert=%s sslkey=%s sslpassword=secret", rootCert, sslCert, sslKey), false},
+		{"missing client certificate", certPort, "sslmode=verify-full sslrootcert=" + rootCert, true},
+		{"wrong sslpassword", certPort, fmt.Sprintf("sslmode=verify-full sslrootcert=%s sslcert=%s sslkey=%s sslpassword=wrong", rootCert, sslCert, sslKey), true},
+		{"verify-full without sni", port, "sslmode=verify-full sslsni=0 sslrootcert=" + rootCert, false},
+		{"verify-full with wrong host", otherPort, "sslmode=verify-full sslrootcert=" + rootCert, true},
+		{"verify-ca with wrong host", otherPort, "sslmode=verify-ca sslrootcert=" + rootCert, false},
+		{"verify-full without root certificate", port, "sslmode=verify-full", true},
+		{"require with root certificate", otherPort, "sslmode=require sslrootcert=" + filepath.Join(dir, "client.crt"), true},
+		{"revoked certificate", port, fmt.Sprintf("sslmode=verify-full sslrootcert=%s sslcrl=%s", rootCert, sslCRL), true},
+		{"revocation list without the certificate", otherPort, fmt.Sprintf("sslmode=verify-ca sslrootcert=%s sslcrl=%s", rootCert, sslCRL), false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			connStr := fmt.Sprintf("host=localhost port=%s user=postgres dbname=postgres %s", tc.port, tc.params)
+			conn, err := protocol.NewPgConnection(connStr, models.DriveConfig{})
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error = %v, got %v", tc.expectErr, err)
+			}
+			if conn != nil {
+				conn.Close()
+			}
+		})
+	}
+}
+
+func TestSSLLegacyEncryptedKey(t *testing.T) {
+	dir := t.TempDir()
+
+	ca := newTestCA(t)
+	clientCert, _ := ca.issue(t, 2, "postgres", x509.ExtKeyUsageClientAuth)
+	sslCert := writePEM(t, dir, "client.crt", "CERTIFICATE", clientCert.Raw)
+
+	// the key is rejected before it is decrypted
+	sslKey := filepath.Join(dir, "client.key")
+	legacy := pem.EncodeToMemory(&pem.Block{
+		Type:    "EC PRIVATE KEY",
+		Headers: map[string]string{"Proc-Type": "4,ENCRYPTED", "DEK-Info": "AES-256-CBC,00000000000000000000000000000000"},
+		Bytes:   make([]byte, 48),
+	})
+	if err := os.WriteFile(sslKey, legacy, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	port := mockServer{}.start(t).port
+	connStr := fmt.Sprintf("host=localhost port=%s user=postgres sslmode=require sslcert=%s sslkey=%s sslpassword=secret", port, sslCert, sslKey)
+	conn, err := protocol.NewPgConnection(connStr, models.DriveConfig{})
+	if err == nil {
+		conn.Close()
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "PKCS#8") {
+		t.Errorf("expected a hint to convert the key to PKCS#8, got %v", err)
+	}
+}
+
+func TestSSLModeRequireWithoutSSL(t *testing.T) {
+	// the server refuses SSL
+	addr := mockServer{}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres?sslmode=require", models.DriveConfig{})
+	if err == nil {
+		conn.Close()
+		t.Fatal("expected sslmode=require to fail without SSL")
+	}
+}
+
+func TestSSLModePreferWithoutSSL(t *testing.T) {
+	// the server refuses SSL
+	addr := mockServer{}.start(t).addr
+
+	conn, err := protocol.NewPgConnection("postgres://postgres@"+addr+"/postgres?sslmode=prefer", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
+
+func TestSSLNegotiationDirect(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+
+	ca := newTestCA(t)
+	rootCert := writePEM(t, dir, "root.crt", "CERTIFICATE", ca.cert.Raw)
+	serverCert, serverKey := ca.issue(t, 2, "localhost", x509.ExtKeyUsageServerAuth)
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		NextProtos:   []string{"postgresql"},
+	}
+	server := mockServer{tls: config, directTLS: true}.start(t)
+	port := server.port
+
+	connStr := fmt.Sprintf("host=localhost port=%s user=postgres dbname=postgres sslnegotiation=direct sslmode=verify-full sslrootcert=%s", port, rootCert)
+	for i, wantResumed := range []bool{false, true} {
+		conn, err := protocol.NewPgConnection(connStr, models.DriveConfig{})
+		if err != nil {
+			t.Fatalf("connection %d: %v", i, err)
+		}
+		// The session ticket arrives after the handshake, query to read it.
+		if _, err := conn.Query("SELECT 1"); err != nil {
+			t.Fatalf("connection %d: %v", i, err)
+		}
+		conn.Close()
+		if got := (<-server.conns).tls.DidResume; got != wantResumed {
+			t.Errorf("connection %d: expected resumed = %v, got %v", i, wantResumed, got)
+		}
+	}
+
+	noALPN := config.Clone()
+	noALPN.NextProtos = nil
+	noALPNPort := mockServer{tls: noALPN, directTLS: true}.start(t).port
+
+	tests := []struct {
+		name   string
+		port   string
+		params string
+	}{
+		{"server without ALPN", noALPNPort, "sslmode=require"},
+		{"weak sslmode", port, "sslmode=prefer"},
+		{"invalid value", port, "sslmode=require sslnegotiation=tls"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			connStr := fmt.Sprintf("host=localhost port=%s user=postgres dbname=postgres sslnegotiation=direct %s", tc.port, tc.params)
+			conn, err := protocol.NewPgConnection(connStr, models.DriveConfig{})
+			if err == nil {
+				conn.Close()
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestSSLModeDefault(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // no ~/.postgresql files
+	t.Setenv("PGSSLMODE", "")
+	ca := newTestCA(t)
+	cert, key := ca.issue(t, 2, "localhost", x509.ExtKeyUsageServerAuth)
+
+	tests := []struct {
+		name    string
+		server  mockServer
+		withSSL bool
+	}{
+		{"server with SSL", mockServer{tls: &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: key}}}}, true},
+		{"server refusing SSL", mockServer{}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := tc.server.start(t)
+			conn, err := protocol.NewPgConnection("host=localhost port="+server.port+" user=postgres", models.DriveConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn.Close()
+
+			if withSSL := (<-server.conns).tls != nil; withSSL != tc.withSSL {
+				t.Errorf("expected SSL = %v, got %v", tc.withSSL, withSSL)
+			}
+		})
+	}
+}
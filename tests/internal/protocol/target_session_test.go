@@ -0,0 +1,177 @@
+package protocol_test
+
+import (
+	"net"
+	"postgres-protocol-go/internal/protocol"
+	"postgres-protocol-go/pkg/models"
+	"strings"
+	"testing"
+)
+
+// sessionServer is a mock server in hot standby or not. Servers that report
+// their state send in_hot_standby and default_transaction_read_only at
+// startup like PostgreSQL 14+, others answer the queries of older versions.
+type sessionServer struct {
+	name     string
+	standby  bool
+	reported bool
+}
+
+func (s sessionServer) start(t *testing.T) string {
+	onOff := map[bool]string{true: "on", false: "off"}
+	server := mockServer{
+		reply: func(conn net.Conn, identifier byte, body []byte) {
+			if identifier != 'Q' {
+				return
+			}
+			switch query := strings.TrimSuffix(string(body), "\x00"); query {
+			case "SHOW transaction_read_only":
+				conn.Write(valueRow(25, onOff[s.standby]))
+			case "SELECT pg_catalog.pg_is_in_recovery()":
+				conn.Write(valueRow(16, map[bool]string{true: "t", false: "f"}[s.standby]))
+			default:
+				conn.Write(valueRow(25, s.name))
+			}
+		},
+	}
+	if s.reported {
+		server.params = map[string]string{
+			"in_hot_standby":                onOff[s.standby],
+			"default_transaction_read_only": "off",
+		}
+	}
+	return server.start(t).port
+}
+
+// closedPort returns a port nothing listens on.
+func closedPort(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+	return port
+}
+
+// connectedServer returns the name of the server conn is connected to.
+func connectedServer(t *testing.T, conn *protocol.PgConnection) string {
+	result, err := conn.Query("SELECT name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Values[0][0].(string)
+}
+
+func TestTargetSessionAttrs(t *testing.T) {
+	servers := map[string]string{"down": closedPort(t)}
+	for _, server := range []sessionServer{
+		{name: "primary", reported: true},
+		{name: "standby", standby: true, reported: true},
+		{name: "old primary"},
+		{name: "old standby", standby: true},
+	} {
+		servers[server.name] = server.start(t)
+	}
+
+	connStr := func(names []string, params string) string {
+		hosts := make([]string, len(names))
+		ports := make([]string, len(names))
+		for i, name := range names {
+			hosts[i], ports[i] = "127.0.0.1", servers[name]
+		}
+		return "host=" + strings.Join(hosts, ",") + " port=" + strings.Join(ports, ",") + " user=postgres " + params
+	}
+
+	tests := []struct {
+		name     string
+		hosts    []string
+		params   string
+		expected string
+	}{
+		{"first available host", []string{"down", "standby", "primary"}, "", "standby"},
+		{"read-write", []string{"down", "standby", "primary"}, "target_session_attrs=read-write", "primary"},
+		{"read-only", []string{"primary", "standby"}, "target_session_attrs=read-only", "standby"},
+		{"primary", []string{"standby", "primary"}, "target_session_attrs=primary", "primary"},
+		{"standby", []string{"primary", "standby"}, "target_session_attrs=standby", "standby"},
+		{"prefer-standby", []string{"primary", "standby"}, "target_session_attrs=prefer-standby", "standby"},
+		{"prefer-standby without standby", []string{"down", "primary"}, "target_session_attrs=prefer-standby", "primary"},
+		{"read-write queried", []string{"old standby", "old primary"}, "target_session_attrs=read-write", "old primary"},
+		{"primary queried", []string{"old standby", "old primary"}, "target_session_attrs=primary", "old primary"},
+		{"standby queried", []string{"old primary", "old standby"}, "target_session_attrs=standby", "old standby"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			conn, err := protocol.NewPgConnection(connStr(tc.hosts, tc.params), models.DriveConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			if got := connectedServer(t, conn); got != tc.expected {
+				t.Errorf("expected to connect to %s, got %s", tc.expected, got)
+			}
+		})
+	}
+
+	t.Run("URL", func(t *testing.T) {
+		url := "postgres://postgres@127.0.0.1:" + servers["standby"] + ",127.0.0.1:" + servers["primary"] + "/postgres?target_session_attrs=read-write"
+		conn, err := protocol.NewPgConnection(url, models.DriveConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		if got := connectedServer(t, conn); got != "primary" {
+			t.Errorf("expected to connect to primary, got %s", got)
+		}
+	})
+
+	t.Run("no matching host", func(t *testing.T) {
+		conn, err := protocol.NewPgConnection(connStr([]string{"down", "standby"}, "target_session_attrs=read-write"), models.DriveConfig{})
+		if err == nil {
+			conn.Close()
+			t.Fatal("expected an error")
+		}
+		for _, name := range []string{"down", "standby"} {
+			if !strings.Contains(err.Error(), "port "+servers[name]) {
+				t.Errorf("expected the error of %s in %q", name, err)
+			}
+		}
+		if !strings.Contains(err.Error(), "session is read-only") {
+			t.Errorf("expected a read-only session error, got %q", err)
+		}
+	})
+
+	t.Run("load_balance_hosts=random", func(t *testing.T) {
+		seen := map[string]bool{}
+		for i := 0; i < 100 && len(seen) < 2; i++ {
+			conn, err := protocol.NewPgConnection(connStr([]string{"primary", "standby"}, "load_balance_hosts=random"), models.DriveConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			seen[connectedServer(t, conn)] = true
+			conn.Close()
+		}
+		if len(seen) != 2 {
+			t.Errorf("expected connections to both hosts, got %v", seen)
+		}
+	})
+}
+
+func TestMultipleHostsInvalid(t *testing.T) {
+	for _, connStr := range []string{
+		"host=a,b,c port=5432,5433",
+		"host=a port=5432,x",
+		"host=a target_session_attrs=writable",
+		"host=a load_balance_hosts=always",
+		"postgres://[::1:5432/postgres",
+	} {
+		conn, err := protocol.NewPgConnection(connStr, models.DriveConfig{})
+		if err == nil {
+			conn.Close()
+			t.Errorf("expected an error for %q", connStr)
+		}
+	}
+}
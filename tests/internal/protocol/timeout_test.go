@@ -0,0 +1,96 @@
+package protocol_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"postgres-protocol-go/internal/protocol"
+	"postgres-protocol-go/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestConnectTimeouts(t *testing.T) {
+	port := mockServer{silent: true}.start(t).port
+
+	tests := []struct {
+		name    string
+		params  string
+		timeout time.Duration
+	}{
+		{"read timeout in authentication", "read_timeout=100ms", time.Second},
+		{"read timeout in SSL negotiation", "sslmode=require read_timeout=100ms", time.Second},
+		{"read timeout in TLS handshake", "sslmode=require sslnegotiation=direct read_timeout=100ms", time.Second},
+		{"connect timeout", "connect_timeout=2 read_timeout=10", 4 * time.Second},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.timeout > time.Second && testing.Short() {
+				t.Skip("slow timeout")
+			}
+
+			start := time.Now()
+			conn, err := protocol.NewPgConnection("host=127.0.0.1 port="+port+" user=postgres "+tc.params, models.DriveConfig{})
+			if err == nil {
+				conn.Close()
+				t.Fatal("expected an error")
+			}
+			if !errors.Is(err, os.ErrDeadlineExceeded) {
+				t.Errorf("expected a deadline error, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > tc.timeout {
+				t.Errorf("expected the connection to time out within %v, took %v", tc.timeout, elapsed)
+			}
+		})
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	// queries are never answered
+	port := mockServer{reply: func(net.Conn, byte, []byte) {}}.start(t).port
+
+	conn, err := protocol.NewPgConnection("host=127.0.0.1 port="+port+" user=postgres read_timeout=100ms write_timeout=1", models.DriveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Query("SELECT 1")
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+}
+
+func TestKeepalives(t *testing.T) {
+	server := mockServer{}.start(t)
+
+	for _, params := range []string{
+		"keepalives=0",
+		"keepalives=1 keepalives_idle=30 keepalives_interval=5 keepalives_count=3 tcp_user_timeout=10000",
+	} {
+		conn, err := protocol.NewPgConnection("host=127.0.0.1 port="+server.port+" user=postgres "+params, models.DriveConfig{})
+		if err != nil {
+			t.Fatalf("%s: %v", params, err)
+		}
+		conn.Close()
+	}
+}
+
+func TestTimeoutParamsInvalid(t *testing.T) {
+	for _, params := range []string{
+		"read_timeout=fast",
+		"write_timeout=-1s",
+		"keepalives=yes",
+		"keepalives_idle=x",
+		"keepalives_interval=-1",
+		"keepalives_count=-1",
+		"tcp_user_timeout=soon",
+	} {
+		conn, err := protocol.NewPgConnection("host=127.0.0.1 "+params, models.DriveConfig{})
+		if err == nil {
+			conn.Close()
+			t.Errorf("expected an error for %q", params)
+		}
+	}
+}
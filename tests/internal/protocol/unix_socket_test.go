@@ -0,0 +1,57 @@
+package protocol_test
+
+import (
+	"net/url"
+	"path/filepath"
+	"postgres-protocol-go/internal/protocol"
+	"postgres-protocol-go/pkg/models"
+	"runtime"
+	"testing"
+)
+
+func TestUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets are not used on Windows")
+	}
+
+	dir := t.TempDir()
+	server := mockServer{network: "unix", address: filepath.Join(dir, ".s.PGSQL.5433")}.start(t)
+
+	escaped := url.PathEscape(dir)
+	tests := []struct {
+		name    string
+		connStr string
+	}{
+		{"host directory", "host=" + dir + " port=5433 user=postgres"},
+		{"URL host", "postgres://postgres@" + escaped + ":5433/postgres"},
+		{"URL host parameter", "postgresql:///postgres?host=" + url.QueryEscape(dir) + "&port=5433&user=postgres"},
+		{"sslmode is ignored", "host=" + dir + " port=5433 user=postgres sslmode=require"},
+		{"sslnegotiation is ignored", "host=" + dir + " port=5433 user=postgres sslmode=require sslnegotiation=direct"},
+		{"failover to the socket", "host=" + filepath.Join(dir, "missing") + "," + dir + " port=5433 user=postgres"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			conn, err := protocol.NewPgConnection(tc.connStr, models.DriveConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			if _, err := conn.Query("SELECT 1"); err != nil {
+				t.Fatal(err)
+			}
+			if state := (<-server.conns).tls; state != nil {
+				t.Error("expected no TLS on the Unix socket")
+			}
+		})
+	}
+
+	t.Run("wrong port", func(t *testing.T) {
+		conn, err := protocol.NewPgConnection("host="+dir+" port=5432 user=postgres", models.DriveConfig{})
+		if err == nil {
+			conn.Close()
+			t.Fatal("expected an error")
+		}
+	})
+}
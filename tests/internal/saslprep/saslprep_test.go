@@ -0,0 +1,67 @@
+package saslprep_test
+
+import (
+	"errors"
+	"postgres-protocol-go/internal/saslprep"
+	"testing"
+)
+
+func TestPrepare(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      error
+	}{
+		// RFC 4013, section 3
+		{"soft hyphen mapped to nothing", "I\u00ADX", "IX", nil},
+		{"no transformation", "user", "user", nil},
+		{"case preserved", "USER", "USER", nil},
+		{"output is NFKC", "ª", "a", nil},
+		{"output is NFKC roman numeral", "Ⅸ", "IX", nil},
+		{"bidi check", "ا1", "", saslprep.ErrProhibited},
+
+		{"ASCII returned as is", "a\u0007b", "a\u0007b", nil},
+		{"non-ASCII space", "a\u00A0b\u3000c", "a b c", nil},
+		{"canonical composition", "e\u0301", "é", nil},
+		{"canonical reordering", "a\u0301\u0323", "ạ\u0301", nil},
+		{"Hangul composition", "\u1100\u1161\u11A8", "각", nil},
+		{"compatibility decomposition", "ﬁx", "fix", nil},
+		{"right-to-left", "ا1ب", "ا1ب", nil},
+		{"right-to-left and left-to-right", "اaب", "", saslprep.ErrProhibited},
+		{"non-ASCII control character", "a\u0080", "", saslprep.ErrProhibited},
+		{"private use", "a\uE000", "", saslprep.ErrProhibited},
+		{"unassigned in Unicode 3.2", "aȡ", "", saslprep.ErrProhibited},
+		{"empty after mapping", "\u00AD\u034F", "", saslprep.ErrProhibited},
+		{"invalid UTF-8", "é\xff", "", saslprep.ErrInvalidUTF8},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := saslprep.Prepare(tc.input)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %+q, got %+q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestPassword(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"I\u00ADXⅨ", "IXIX"},
+		{"ا1", "ا1"},
+		{"é\xff", "é\xff"},
+	}
+
+	for _, tc := range tests {
+		if result := saslprep.Password(tc.input); result != tc.expected {
+			t.Errorf("Password(%+q): expected %+q, got %+q", tc.input, tc.expected, result)
+		}
+	}
+}
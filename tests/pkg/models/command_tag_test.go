@@ -0,0 +1,33 @@
+package models_test
+
+import (
+	"postgres-protocol-go/pkg/models"
+	"testing"
+)
+
+func TestCommandTag(t *testing.T) {
+	tests := []struct {
+		tag          models.CommandTag
+		command      string
+		rowsAffected int64
+	}{
+		{"INSERT 0 5", "INSERT", 5},
+		{"UPDATE 3", "UPDATE", 3},
+		{"DELETE 0", "DELETE", 0},
+		{"SELECT 10", "SELECT", 10},
+		{"COPY 1000", "COPY", 1000},
+		{"MERGE 2", "MERGE", 2},
+		{"CREATE TABLE", "CREATE TABLE", 0},
+		{"BEGIN", "BEGIN", 0},
+		{"", "", 0},
+	}
+
+	for _, tc := range tests {
+		if got := tc.tag.Command(); got != tc.command {
+			t.Errorf("CommandTag(%q).Command() = %q, want %q", tc.tag, got, tc.command)
+		}
+		if got := tc.tag.RowsAffected(); got != tc.rowsAffected {
+			t.Errorf("CommandTag(%q).RowsAffected() = %d, want %d", tc.tag, got, tc.rowsAffected)
+		}
+	}
+}
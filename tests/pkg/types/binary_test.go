@@ -0,0 +1,54 @@
+package types_test
+
+import (
+	"bytes"
+	"postgres-protocol-go/pkg/types"
+	"testing"
+)
+
+func TestAppendBinaryParam(t *testing.T) {
+	data, oid, ok, err := types.AppendBinaryParam(nil, int64(-2))
+	if err != nil || !ok {
+		t.Fatalf("AppendBinaryParam int64 = %v, %v", ok, err)
+	}
+	if oid != types.Int8OID || !bytes.Equal(data, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe}) {
+		t.Fatalf("AppendBinaryParam int64 = %d, %x", oid, data)
+	}
+
+	data, oid, ok, err = types.AppendBinaryParam(nil, []int32{1, 2})
+	if err != nil || !ok {
+		t.Fatalf("AppendBinaryParam []int32 = %v, %v", ok, err)
+	}
+	want := []byte{
+		0, 0, 0, 1, // ndim
+		0, 0, 0, 0, // has nulls
+		0, 0, 0, 23, // int4
+		0, 0, 0, 2, // length
+		0, 0, 0, 1, // lower bound
+		0, 0, 0, 4, 0, 0, 0, 1,
+		0, 0, 0, 4, 0, 0, 0, 2,
+	}
+	if oid != types.Int4ArrayOID || !bytes.Equal(data, want) {
+		t.Fatalf("AppendBinaryParam []int32 = %d, %v", oid, data)
+	}
+
+	if _, _, ok, _ := types.AppendBinaryParam(nil, "text"); ok {
+		t.Fatal("AppendBinaryParam string should fall back to text")
+	}
+}
+
+func TestAppendBinaryParamRoundTrip(t *testing.T) {
+	data, oid, ok, err := types.AppendBinaryParam(nil, []float64{1.5, -2})
+	if err != nil || !ok || oid != types.Float8ArrayOID {
+		t.Fatalf("AppendBinaryParam []float64 = %d, %v, %v", oid, ok, err)
+	}
+	values, err := types.ParseArray(data, types.BinaryFormat, func(src []byte, format int16) (interface{}, error) {
+		return types.DecodeValue(types.Float8OID, src, format)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0] != 1.5 || values[1] != -2.0 {
+		t.Fatalf("ParseArray = %v", values)
+	}
+}
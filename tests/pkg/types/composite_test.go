@@ -0,0 +1,73 @@
+package types_test
+
+import (
+	"postgres-protocol-go/pkg/types"
+	"reflect"
+	"testing"
+)
+
+type inventoryItem struct {
+	Name     string
+	Supplier *int32 `pg:"supplier_id"`
+	Price    float64
+}
+
+func TestScanCompositeText(t *testing.T) {
+	var item inventoryItem
+	err := types.ScanComposite(&item, []byte(`("fuzzy dice",42,1.99)`), types.TextFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Name != "fuzzy dice" || item.Supplier == nil || *item.Supplier != 42 || item.Price != 1.99 {
+		t.Fatalf("unexpected item %+v", item)
+	}
+
+	var values []interface{}
+	err = types.ScanComposite(&values, []byte(`(a,,"",  "say ""hi""\\")`), types.TextFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"a", nil, "", `  say "hi"\`}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("ScanComposite = %#v, want %#v", values, want)
+	}
+}
+
+func TestCompositeTypeBinaryRoundTrip(t *testing.T) {
+	composite := types.NewCompositeType("inventory_item", 16400, []types.CompositeField{
+		{Name: "name", OID: types.TextOID},
+		{Name: "supplier_id", OID: types.Int4OID},
+		{Name: "price", OID: types.Float8OID},
+	})
+
+	b, err := composite.AppendBinary(nil, inventoryItem{Name: "dice", Price: 2.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var item inventoryItem
+	if err := composite.Scan(&item, b, types.BinaryFormat); err != nil {
+		t.Fatal(err)
+	}
+	if item.Name != "dice" || item.Supplier != nil || item.Price != 2.5 {
+		t.Fatalf("unexpected item %+v", item)
+	}
+
+	decoded, err := composite.Decode(b, types.BinaryFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"dice", nil, 2.5}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Fatalf("Decode = %#v, want %#v", decoded, want)
+	}
+}
+
+func TestAppendStructAsComposite(t *testing.T) {
+	supplier := int32(7)
+	got := types.Append(nil, inventoryItem{Name: `big "red" box`, Supplier: &supplier, Price: 3}, 0)
+	want := `("big ""red"" box",7,3)`
+	if string(got) != want {
+		t.Fatalf("Append = %s, want %s", got, want)
+	}
+}
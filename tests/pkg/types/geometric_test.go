@@ -0,0 +1,95 @@
+package types_test
+
+import (
+	"math"
+	"postgres-protocol-go/pkg/types"
+	"reflect"
+	"testing"
+)
+
+func TestGeometricText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		value types.ValueScanner
+		want  interface{}
+	}{
+		{
+			name:  "Point",
+			text:  "(1.5,-2)",
+			value: new(types.Point),
+			want:  &types.Point{X: 1.5, Y: -2},
+		},
+		{
+			name:  "Line",
+			text:  "{1,-1,0}",
+			value: new(types.Line),
+			want:  &types.Line{A: 1, B: -1, C: 0},
+		},
+		{
+			name:  "Lseg",
+			text:  "[(0,0),(1,1)]",
+			value: new(types.Lseg),
+			want:  &types.Lseg{P: [2]types.Point{{X: 0, Y: 0}, {X: 1, Y: 1}}},
+		},
+		{
+			name:  "Box",
+			text:  "(2,2),(0,0)",
+			value: new(types.Box),
+			want:  &types.Box{P: [2]types.Point{{X: 2, Y: 2}, {X: 0, Y: 0}}},
+		},
+		{
+			name:  "Open path",
+			text:  "[(0,0),(1,1),(2,0)]",
+			value: new(types.Path),
+			want:  &types.Path{Points: []types.Point{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 0}}},
+		},
+		{
+			name:  "Polygon",
+			text:  "((0,0),(1,1),(2,0))",
+			value: new(types.Polygon),
+			want:  &types.Polygon{Points: []types.Point{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 0}}},
+		},
+		{
+			name:  "Circle",
+			text:  "<(1,2),3.25>",
+			value: new(types.Circle),
+			want:  &types.Circle{Center: types.Point{X: 1, Y: 2}, Radius: 3.25},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.value.ScanValue([]byte(tc.text), types.TextFormat); err != nil {
+				t.Fatalf("ScanValue failed: %v", err)
+			}
+			if !reflect.DeepEqual(tc.value, tc.want) {
+				t.Fatalf("ScanValue = %+v, want %+v", tc.value, tc.want)
+			}
+
+			got := types.Append(nil, tc.value, 0)
+			if string(got) != tc.text {
+				t.Fatalf("Append = %s, want %s", got, tc.text)
+			}
+
+			b, err := tc.value.(types.BinaryAppender).AppendBinary(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded := reflect.New(reflect.TypeOf(tc.value).Elem()).Interface().(types.ValueScanner)
+			if err := decoded.ScanValue(b, types.BinaryFormat); err != nil {
+				t.Fatalf("binary ScanValue failed: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tc.want) {
+				t.Fatalf("binary round trip = %+v, want %+v", decoded, tc.want)
+			}
+		})
+	}
+}
+
+func TestPointInfinity(t *testing.T) {
+	p := types.Point{X: math.Inf(1), Y: 0}
+	if got := string(types.Append(nil, p, 0)); got != "(Infinity,0)" {
+		t.Fatalf("Append = %s", got)
+	}
+}
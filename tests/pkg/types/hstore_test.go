@@ -0,0 +1,63 @@
+package types_test
+
+import (
+	"postgres-protocol-go/pkg/types"
+	"reflect"
+	"testing"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestHstore(t *testing.T) {
+	value := map[string]*string{
+		"a":         strPtr("1"),
+		"quote\"me": strPtr(`back\slash`),
+		"empty":     strPtr(""),
+		"null":      nil,
+	}
+
+	text := types.Append(nil, value, 0)
+	want := `"a"=>"1", "empty"=>"", "null"=>NULL, "quote\"me"=>"back\\slash"`
+	if string(text) != want {
+		t.Fatalf("Append = %s, want %s", text, want)
+	}
+
+	var fromText map[string]*string
+	if err := types.Scan(&fromText, text, types.TextFormat); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromText, value) {
+		t.Fatalf("text round trip = %v, want %v", fromText, value)
+	}
+
+	b, err := types.Hstore(value).AppendBinary(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromBinary types.Hstore
+	if err := fromBinary.ScanValue(b, types.BinaryFormat); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(map[string]*string(fromBinary), value) {
+		t.Fatalf("binary round trip = %v, want %v", fromBinary, value)
+	}
+}
+
+func TestHstoreNUL(t *testing.T) {
+	for i, value := range []map[string]*string{
+		{"a\x00b": strPtr("1")},
+		{"a": strPtr("1\x002")},
+	} {
+		if _, err := types.Hstore(value).AppendValue(nil, 0); err == nil {
+			t.Errorf("AppendValue %d should fail", i)
+		}
+		if _, err := types.Hstore(value).AppendBinary(nil); err == nil {
+			t.Errorf("AppendBinary %d should fail", i)
+		}
+		if _, _, err := types.AppendParam(nil, 0, value); err == nil {
+			t.Errorf("AppendParam %d should fail", i)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package types_test
+
+import (
+	"net"
+	"net/netip"
+	"postgres-protocol-go/pkg/types"
+	"testing"
+)
+
+func TestScanInet(t *testing.T) {
+	var ip net.IP
+	if err := types.Scan(&ip, []byte("192.168.1.5"), types.TextFormat); err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.ParseIP("192.168.1.5")) {
+		t.Fatalf("Scan net.IP = %v", ip)
+	}
+
+	var ipNet net.IPNet
+	if err := types.Scan(&ipNet, []byte("2001:db8::1/64"), types.TextFormat); err != nil {
+		t.Fatal(err)
+	}
+	if ipNet.String() != "2001:db8::1/64" {
+		t.Fatalf("Scan net.IPNet = %v", ipNet.String())
+	}
+
+	// 10.1.0.0/16 as sent by the server for a cidr column.
+	src := []byte{2, 16, 1, 4, 10, 1, 0, 0}
+	var prefix netip.Prefix
+	if err := types.Scan(&prefix, src, types.BinaryFormat); err != nil {
+		t.Fatal(err)
+	}
+	if prefix != netip.MustParsePrefix("10.1.0.0/16") {
+		t.Fatalf("Scan netip.Prefix = %v", prefix)
+	}
+
+	b := types.AppendInetBinary(nil, prefix, true)
+	if string(b) != string(src) {
+		t.Fatalf("AppendInetBinary = %v, want %v", b, src)
+	}
+}
+
+func TestScanMacaddr(t *testing.T) {
+	var mac net.HardwareAddr
+	if err := types.Scan(&mac, []byte("08:00:2b:01:02:03"), types.TextFormat); err != nil {
+		t.Fatal(err)
+	}
+	if mac.String() != "08:00:2b:01:02:03" {
+		t.Fatalf("Scan macaddr = %v", mac)
+	}
+
+	if err := types.Scan(&mac, []byte{8, 0, 0x2b, 1, 2, 3, 4, 5}, types.BinaryFormat); err != nil {
+		t.Fatal(err)
+	}
+	if mac.String() != "08:00:2b:01:02:03:04:05" {
+		t.Fatalf("Scan macaddr8 = %v", mac)
+	}
+}
+
+func TestAppendIP(t *testing.T) {
+	if got := string(types.Append(nil, net.ParseIP("::1"), 0)); got != "::1" {
+		t.Fatalf("Append net.IP = %s", got)
+	}
+}
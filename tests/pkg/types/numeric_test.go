@@ -0,0 +1,101 @@
+package types_test
+
+import (
+	"bytes"
+	"math/big"
+	"postgres-protocol-go/pkg/types"
+	"testing"
+)
+
+func TestNumericBinaryRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		bytes []byte
+	}{
+		{
+			name:  "Zero",
+			text:  "0",
+			bytes: []byte{0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:  "Integer",
+			text:  "12345678",
+			bytes: []byte{0, 2, 0, 1, 0, 0, 0, 0, 0x04, 0xD2, 0x16, 0x2E},
+		},
+		{
+			name:  "Negative with scale",
+			text:  "-1234.500000",
+			bytes: []byte{0, 2, 0, 0, 0x40, 0, 0, 6, 0x04, 0xD2, 0x13, 0x88},
+		},
+		{
+			name:  "Small fraction",
+			text:  "0.00005",
+			bytes: []byte{0, 1, 0xFF, 0xFE, 0, 0, 0, 5, 0x13, 0x88},
+		},
+		{
+			name:  "NaN",
+			text:  "NaN",
+			bytes: []byte{0, 0, 0, 0, 0xC0, 0, 0, 0},
+		},
+		{
+			name:  "Negative infinity",
+			text:  "-Infinity",
+			bytes: []byte{0, 0, 0, 0, 0xF0, 0, 0, 0},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := types.ParseNumeric(tc.text)
+			if err != nil {
+				t.Fatalf("ParseNumeric(%q) failed: %v", tc.text, err)
+			}
+
+			got, err := n.AppendBinary(nil)
+			if err != nil {
+				t.Fatalf("AppendBinary failed: %v", err)
+			}
+			if !bytes.Equal(got, tc.bytes) {
+				t.Fatalf("AppendBinary(%q) = %v, want %v", tc.text, got, tc.bytes)
+			}
+
+			decoded, err := types.DecodeNumericBinary(tc.bytes)
+			if err != nil {
+				t.Fatalf("DecodeNumericBinary failed: %v", err)
+			}
+			if decoded.String() != tc.text {
+				t.Fatalf("DecodeNumericBinary = %s, want %s", decoded.String(), tc.text)
+			}
+		})
+	}
+}
+
+func TestNumericConversions(t *testing.T) {
+	n, err := types.ParseNumeric("1.5e3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err := n.BigInt()
+	if err != nil || i.Int64() != 1500 {
+		t.Fatalf("BigInt() = %v, %v, want 1500", i, err)
+	}
+
+	r, _ := new(big.Rat).SetString("-2/3")
+	if got := types.NumericFromRat(r, 6).String(); got != "-0.666667" {
+		t.Fatalf("NumericFromRat(-2/3, 6) = %s, want -0.666667", got)
+	}
+
+	n, _ = types.ParseNumeric("12345678901234567890.123456")
+	rat, err := n.Rat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := types.NumericFromRat(rat, 6).String(); got != "12345678901234567890.123456" {
+		t.Fatalf("round trip through big.Rat = %s", got)
+	}
+
+	if _, err := (types.Numeric{Int: big.NewInt(15), Scale: 1}).BigInt(); err == nil {
+		t.Fatal("expected error converting 1.5 to big.Int")
+	}
+}
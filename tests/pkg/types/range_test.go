@@ -0,0 +1,112 @@
+package types_test
+
+import (
+	"postgres-protocol-go/pkg/types"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRangeText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		value types.Range[int64]
+	}{
+		{
+			name:  "Half open",
+			text:  "[1,10)",
+			value: types.NewRange[int64](1, 10),
+		},
+		{
+			name:  "Inclusive upper",
+			text:  "(1,10]",
+			value: types.Range[int64]{Lower: 1, Upper: 10, UpperInclusive: true},
+		},
+		{
+			name:  "Infinite lower",
+			text:  "(,10)",
+			value: types.Range[int64]{Upper: 10, LowerInfinite: true},
+		},
+		{
+			name:  "Empty",
+			text:  "empty",
+			value: types.Range[int64]{Empty: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := types.Append(nil, tc.value, 0)
+			if string(got) != tc.text {
+				t.Fatalf("Append = %s, want %s", got, tc.text)
+			}
+
+			var r types.Range[int64]
+			if err := r.ScanValue([]byte(tc.text), types.TextFormat); err != nil {
+				t.Fatalf("ScanValue failed: %v", err)
+			}
+			if r != tc.value {
+				t.Fatalf("ScanValue = %+v, want %+v", r, tc.value)
+			}
+		})
+	}
+}
+
+func TestRangeBinaryRoundTrip(t *testing.T) {
+	lower := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	r := types.Range[time.Time]{Lower: lower, LowerInclusive: true, UpperInfinite: true}
+
+	b, err := r.AppendBinary(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded types.Range[time.Time]
+	if err := decoded.ScanValue(b, types.BinaryFormat); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Lower.Equal(lower) || !decoded.UpperInfinite || !decoded.LowerInclusive {
+		t.Fatalf("binary round trip = %+v", decoded)
+	}
+}
+
+func TestTstzRangeText(t *testing.T) {
+	var r types.Range[time.Time]
+	err := r.ScanValue([]byte(`["2024-05-01 10:00:00+00","2024-05-01 12:30:00+00")`), types.TextFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Upper.Sub(r.Lower) != 150*time.Minute {
+		t.Fatalf("unexpected range %+v", r)
+	}
+}
+
+func TestMultirange(t *testing.T) {
+	text := "{[1,3),[5,7)}"
+
+	var m types.Multirange[int32]
+	if err := m.ScanValue([]byte(text), types.TextFormat); err != nil {
+		t.Fatal(err)
+	}
+	want := types.Multirange[int32]{types.NewRange[int32](1, 3), types.NewRange[int32](5, 7)}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("ScanValue = %+v, want %+v", m, want)
+	}
+
+	b, err := m.AppendBinary(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded types.Multirange[int32]
+	if err := decoded.ScanValue(b, types.BinaryFormat); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Fatalf("binary round trip = %+v, want %+v", decoded, want)
+	}
+
+	if got := string(types.Append(nil, m, 0)); got != text {
+		t.Fatalf("Append = %s, want %s", got, text)
+	}
+}
@@ -0,0 +1,63 @@
+package types_test
+
+import (
+	"postgres-protocol-go/pkg/types"
+	"reflect"
+	"testing"
+)
+
+func TestRegistryUserTypes(t *testing.T) {
+	registry := types.NewRegistry()
+	registry.Register(types.NewEnumType(16500, "mood", 16499, []string{"sad", "ok", "happy"}))
+	registry.Register(types.NewDomainType(registry, 16510, "positive_int", 16509, types.Int4OID))
+	registry.Register(types.NewArrayType(registry, 16499, "mood[]", 16500))
+
+	mood, ok := registry.TypeByName("mood")
+	if !ok {
+		t.Fatal("mood not registered")
+	}
+	if err := mood.ValidateEnum("happy"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mood.ValidateEnum("angry"); err == nil {
+		t.Fatal("expected error for unknown enum label")
+	}
+
+	v, ok, err := registry.Decode(16510, []byte{0, 0, 0, 42}, types.BinaryFormat)
+	if err != nil || !ok || v != int32(42) {
+		t.Fatalf("Decode domain = %v, %v, %v", v, ok, err)
+	}
+
+	v, ok, err = registry.Decode(16499, []byte(`{sad,NULL,"happy"}`), types.TextFormat)
+	want := []interface{}{"sad", nil, "happy"}
+	if err != nil || !ok || !reflect.DeepEqual(v, want) {
+		t.Fatalf("Decode enum array = %#v, %v, %v", v, ok, err)
+	}
+
+	if _, ok, _ := registry.Decode(types.Int4OID, []byte("1"), types.TextFormat); ok {
+		t.Fatal("built-in types must not be decoded by the registry")
+	}
+}
+
+func TestParseArrayBinary(t *testing.T) {
+	// int4[] {{1,2},{3,NULL}}
+	src := []byte{
+		0, 0, 0, 2, 0, 0, 0, 1, 0, 0, 0, 23,
+		0, 0, 0, 2, 0, 0, 0, 1,
+		0, 0, 0, 2, 0, 0, 0, 1,
+		0, 0, 0, 4, 0, 0, 0, 1,
+		0, 0, 0, 4, 0, 0, 0, 2,
+		0, 0, 0, 4, 0, 0, 0, 3,
+		0xFF, 0xFF, 0xFF, 0xFF,
+	}
+	v, err := types.ParseArray(src, types.BinaryFormat, func(elem []byte, format int16) (interface{}, error) {
+		return types.DecodeValue(types.Int4OID, elem, format)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{[]interface{}{int32(1), int32(2)}, []interface{}{int32(3), nil}}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("ParseArray = %#v, want %#v", v, want)
+	}
+}
@@ -0,0 +1,50 @@
+package types_test
+
+import (
+	"postgres-protocol-go/pkg/types"
+	"testing"
+)
+
+func TestTypeName(t *testing.T) {
+	r := types.NewRegistry()
+	r.Register(types.NewEnumType(16400, "mood", 16399, []string{"sad", "happy"}))
+	r.Register(types.NewArrayType(r, 16399, "mood[]", 16400))
+
+	tests := []struct {
+		oid    uint32
+		typmod int32
+		want   string
+	}{
+		{types.VarcharOID, 255 + 4, "varchar(255)"},
+		{types.VarcharOID, -1, "varchar"},
+		{types.NumericOID, (10<<16 | 2) + 4, "numeric(10,2)"},
+		{types.NumericOID, -1, "numeric"},
+		{types.TimestamptzOID, 3, "timestamptz(3)"},
+		{types.BitOID, 8, "bit(8)"},
+		{types.IntervalOID, (8|1024|2048|4096)<<16 | 3, "interval day to second(3)"},
+		{types.IntervalOID, 0x7FFF<<16 | 0xFFFF, "interval"},
+		{1015, 10 + 4, "varchar(10)[]"},
+		{types.Int4ArrayOID, -1, "int4[]"},
+		{16400, -1, "mood"},
+		{16399, -1, "mood[]"},
+		{99999, -1, ""},
+	}
+
+	for _, tc := range tests {
+		if got := r.TypeName(tc.oid, tc.typmod); got != tc.want {
+			t.Errorf("TypeName(%d, %d) = %q, want %q", tc.oid, tc.typmod, got, tc.want)
+		}
+	}
+}
+
+func TestTypmodPrecisionScale(t *testing.T) {
+	// numeric(5,-2), a negative scale allowed since PostgreSQL 15
+	precision, scale, ok := types.TypmodPrecisionScale(types.NumericOID, (5<<16|0x7FE)+4)
+	if !ok || precision != 5 || scale != -2 {
+		t.Fatalf("TypmodPrecisionScale = %d, %d, %v", precision, scale, ok)
+	}
+
+	if _, _, ok := types.TypmodPrecisionScale(types.Int4OID, 8); ok {
+		t.Fatal("TypmodPrecisionScale should not apply to int4")
+	}
+}
@@ -0,0 +1,106 @@
+package types_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"postgres-protocol-go/pkg/types"
+	"testing"
+	"time"
+)
+
+func TestParamOID(t *testing.T) {
+	if oid := types.ParamOID(types.Typed(types.Int4OID, 1)); oid != types.Int4OID {
+		t.Fatalf("ParamOID Typed = %d", oid)
+	}
+	if oid := types.ParamOID(int64(1)); oid != 0 {
+		t.Fatalf("ParamOID int64 = %d", oid)
+	}
+	if oid := types.ParamOID("a"); oid != 0 {
+		t.Fatalf("ParamOID string = %d", oid)
+	}
+}
+
+func TestAppendParam(t *testing.T) {
+	data, format, err := types.AppendParam(nil, types.Int2OID, 7)
+	if err != nil || format != types.BinaryFormat || !bytes.Equal(data, []byte{0, 7}) {
+		t.Fatalf("AppendParam int2 = %v, %d, %v", data, format, err)
+	}
+
+	if _, _, err := types.AppendParam(nil, types.Int2OID, 70000); err == nil {
+		t.Fatal("AppendParam int2 should fail out of range")
+	}
+
+	data, format, err = types.AppendParam(nil, types.Float8OID, int32(2))
+	if err != nil || format != types.BinaryFormat || !bytes.Equal(data, []byte{0x40, 0, 0, 0, 0, 0, 0, 0}) {
+		t.Fatalf("AppendParam float8 = %v, %d, %v", data, format, err)
+	}
+
+	tm := time.Date(2000, 1, 2, 0, 0, 0, 0, time.FixedZone("", 3600))
+	data, format, err = types.AppendParam(nil, types.TimestampOID, tm)
+	if err != nil || format != types.BinaryFormat || !bytes.Equal(data, []byte{0, 0, 0, 0x14, 0x1d, 0xd7, 0x60, 0}) {
+		t.Fatalf("AppendParam timestamp = %x, %d, %v", data, format, err)
+	}
+
+	data, format, err = types.AppendParam(nil, types.TextOID, 42)
+	if err != nil || format != types.TextFormat || string(data) != "42" {
+		t.Fatalf("AppendParam text = %q, %d, %v", data, format, err)
+	}
+}
+
+func TestAppendParamArrays(t *testing.T) {
+	arrays := []struct {
+		oid      uint32
+		elemOID  uint32
+		elemSize int
+	}{
+		{types.Int2ArrayOID, types.Int2OID, 2},
+		{types.Int4ArrayOID, types.Int4OID, 4},
+		{types.Int8ArrayOID, types.Int8OID, 8},
+		{types.Float4ArrayOID, types.Float4OID, 4},
+		{types.Float8ArrayOID, types.Float8OID, 8},
+		{types.NumericArrayOID, types.NumericOID, 0},
+		{types.TextArrayOID, types.TextOID, 0},
+	}
+	values := []interface{}{[]int{1, 2}, []int64{1, 2}, []string{"a", "b"}}
+
+	for _, array := range arrays {
+		for _, value := range values {
+			data, format, err := types.AppendParam(nil, array.oid, value)
+			_, isStrings := value.([]string)
+			if isStrings && array.oid != types.TextArrayOID {
+				if err == nil {
+					t.Errorf("AppendParam %d %T should fail", array.oid, value)
+				}
+				continue
+			}
+			if err != nil || format != types.BinaryFormat {
+				t.Errorf("AppendParam %d %T = %d, %v", array.oid, value, format, err)
+				continue
+			}
+
+			if elemOID := binary.BigEndian.Uint32(data[8:]); elemOID != array.elemOID {
+				t.Errorf("AppendParam %d %T element type = %d", array.oid, value, elemOID)
+			}
+			elems, err := types.ParseArray(data, types.BinaryFormat, nil)
+			if err != nil || len(elems) != 2 {
+				t.Errorf("AppendParam %d %T = %v, %v", array.oid, value, elems, err)
+				continue
+			}
+			switch {
+			case array.elemSize > 0 && len(elems[0].([]byte)) != array.elemSize:
+				t.Errorf("AppendParam %d %T element = %v", array.oid, value, elems[0])
+			case array.oid == types.TextArrayOID && !isStrings && string(elems[1].([]byte)) != "2":
+				t.Errorf("AppendParam %d %T element = %q", array.oid, value, elems[1])
+			}
+		}
+	}
+
+	for _, value := range []interface{}{[]int{1, 2}, map[string]int{"a": 1}} {
+		if _, _, err := types.AppendParam(nil, 0, value); err == nil {
+			t.Errorf("AppendParam %T without a type should fail", value)
+		}
+	}
+	if got := types.Append(nil, map[string]int{"a": 1}, 0); !bytes.HasPrefix(got, []byte("?!(")) {
+		t.Errorf("Append map = %q, want an error", got)
+	}
+}